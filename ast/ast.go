@@ -55,9 +55,11 @@ func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) String() string       { return i.Value }
 
 type YarStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token
+	Name     *Identifier
+	Pattern  Pattern // set instead of Name when destructuring, e.g. `yar [a, b] be pair.`
+	Operator string  // "+", "-", "*" or "/" for compound forms like `x +be 1`
+	Value    Expression
 }
 
 func (cs *YarStatement) statementNode()       {}
@@ -65,8 +67,12 @@ func (cs *YarStatement) TokenLiteral() string { return cs.Token.Literal }
 func (cs *YarStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(cs.TokenLiteral() + " ")
-	out.WriteString(cs.Name.String())
-	out.WriteString(" be ")
+	if cs.Pattern != nil {
+		out.WriteString(cs.Pattern.String())
+	} else {
+		out.WriteString(cs.Name.String())
+	}
+	out.WriteString(" " + cs.Operator + "be ")
 	if cs.Value != nil {
 		out.WriteString(cs.Value.String())
 	}
@@ -241,19 +247,20 @@ func (bs *BlockStatement) String() string {
 }
 
 type FunctionLiteral struct {
-	Token  token.Token
-	Params []*Identifier
-	Body   *BlockStatement
+	Token    token.Token
+	Name     string // the name it is bound to by `yar name be f(...)` or a chest method
+	Doc      string // a string literal starting a body with more statements after it
+	Params   []*Identifier
+	Defaults map[string]Expression // `b be 1`, keyed by parameter name
+	Rest     *Identifier           // `..rest` collecting extra arguments, or nil
+	Body     *BlockStatement
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
-	params := []string{}
-	for _, p := range fl.Params {
-		params = append(params, p.String())
-	}
+	params := ParamStrings(fl.Params, fl.Defaults, fl.Rest)
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -262,10 +269,32 @@ func (fl *FunctionLiteral) String() string {
 	return out.String()
 }
 
+// ParamStrings formats a parameter list, e.g. [a, b be 1, ..rest].
+func ParamStrings(params []*Identifier, defaults map[string]Expression, rest *Identifier) []string {
+	out := []string{}
+	for _, p := range params {
+		if def, ok := defaults[p.Value]; ok {
+			out = append(out, p.String()+" be "+def.String())
+		} else {
+			out = append(out, p.String())
+		}
+	}
+	if rest != nil {
+		out = append(out, ".."+rest.String())
+	}
+	return out
+}
+
 type CallExpression struct {
-	Token     token.Token // The '(' token
-	Function  Expression
-	Arguments []Expression
+	Token          token.Token // The '(' token
+	Function       Expression
+	Arguments      []Expression
+	NamedArguments []*NamedArgument // `name: value`, after the positional ones
+}
+
+type NamedArgument struct {
+	Name  *Identifier
+	Value Expression
 }
 
 func (ce *CallExpression) expressionNode()      {}
@@ -276,6 +305,9 @@ func (ce *CallExpression) String() string {
 	for _, a := range ce.Arguments {
 		args = append(args, a.String())
 	}
+	for _, a := range ce.NamedArguments {
+		args = append(args, a.Name.String()+": "+a.Value.String())
+	}
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
@@ -292,6 +324,30 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+type InterpolatedString struct {
+	Token token.Token // The token.FSTRING token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	braces := strings.NewReplacer("{", "{{", "}", "}}")
+	out.WriteString("f\"")
+	for _, part := range is.Parts {
+		if sl, ok := part.(*StringLiteral); ok {
+			out.WriteString(braces.Replace(sl.Value))
+			continue
+		}
+		out.WriteString("{")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	out.WriteString("\"")
+	return out.String()
+}
+
 type ArrayLiteral struct {
 	Token    token.Token
 	Elements []Expression
@@ -329,11 +385,37 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
-type IndexAssignment struct {
-	Token token.Token
+type SliceExpression struct {
+	Token token.Token // The '[' token
 	Left  Expression
-	Index Expression
-	Value Expression
+	Start Expression // nil when omitted
+	End   Expression // nil when omitted
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("])")
+	return out.String()
+}
+
+type IndexAssignment struct {
+	Token    token.Token
+	Left     Expression
+	Index    Expression
+	Operator string // "+", "-", "*" or "/" for compound forms like `xs[0] +be 1`
+	Value    Expression
 }
 
 func (ie *IndexAssignment) statementNode()       {}
@@ -344,15 +426,20 @@ func (ia *IndexAssignment) String() string {
 	out.WriteString(ia.Left.String())
 	out.WriteString("[")
 	out.WriteString(ia.Index.String())
-	out.WriteString("] be ")
+	out.WriteString("] " + ia.Operator + "be ")
 	out.WriteString(ia.Value.String())
 	out.WriteString(")")
 	return out.String()
 }
 
+type HashMapPair struct {
+	Key   Expression
+	Value Expression
+}
+
 type HashMapLiteral struct {
 	Token token.Token
-	MP    map[Expression]Expression
+	Pairs []*HashMapPair // in source order
 }
 
 func (tl *HashMapLiteral) expressionNode()      {}
@@ -360,8 +447,8 @@ func (tl *HashMapLiteral) TokenLiteral() string { return tl.Token.Literal }
 func (tl *HashMapLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for key, value := range tl.MP {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range tl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -386,10 +473,51 @@ func (fs *ForStatement) String() string {
 	return out.String()
 }
 
+// ForEachStatement is `4 x in xs: ...`, running the body for every element.
+type ForEachStatement struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fe *ForEachStatement) statementNode()       {}
+func (fe *ForEachStatement) TokenLiteral() string { return fe.Token.Literal }
+func (fe *ForEachStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("4 ")
+	out.WriteString(fe.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fe.Iterable.String())
+	out.WriteString(": ")
+	out.WriteString(fe.Body.String())
+	return out.String()
+}
+
+// FlagStatement declares a flag type and its members, e.g.
+// `flag Color|Red, Green, Blue|.`
+type FlagStatement struct {
+	Token   token.Token // The 'flag' token
+	Name    *Identifier
+	Members []*Identifier
+}
+
+func (fs *FlagStatement) statementNode()       {}
+func (fs *FlagStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *FlagStatement) String() string {
+	members := []string{}
+	for _, m := range fs.Members {
+		members = append(members, m.String())
+	}
+	return "flag " + fs.Name.String() + "|" + strings.Join(members, ", ") + "|."
+}
+
 type ChestStatement struct {
-	Token     token.Token   // The 'chest' token
-	Name      *Identifier   // e.g. myChest
-	FieldList []*Identifier // e.g. [foo, bar]
+	Token     token.Token    // The 'chest' token
+	Name      *Identifier    // e.g. myChest
+	Parent    *Identifier    // the chest type being extended, or nil
+	FieldList []*ChestField  // e.g. [foo, bar be 1]
+	Methods   []*ChestMethod // declared in the block after the fields
 }
 
 func (cs *ChestStatement) statementNode()       {}
@@ -402,15 +530,65 @@ func (cs *ChestStatement) String() string {
 	}
 	out.WriteString("chest ")
 	out.WriteString(cs.Name.String())
+	if cs.Parent != nil {
+		out.WriteString("(")
+		out.WriteString(cs.Parent.String())
+		out.WriteString(")")
+	}
 	out.WriteString("|")
 	out.WriteString(strings.Join(fields, ", "))
-	out.WriteString("|.")
+	out.WriteString("|")
+	if len(cs.Methods) > 0 {
+		out.WriteString(": ")
+		for _, m := range cs.Methods {
+			out.WriteString(m.String())
+			out.WriteString(".")
+		}
+	}
+	out.WriteString(".")
+	return out.String()
+}
+
+// ChestField is one entry of a chest statement's field list, e.g.
+// `gold be 0 if f(g): g >= 0..` or `parrot?`.
+type ChestField struct {
+	Name      *Identifier
+	Optional  bool       // `name?`, the field defaults to MT
+	Default   Expression // `name be expr`, nil when not given
+	Validator Expression // `name if fn`, nil when not given
+}
+
+func (cf *ChestField) String() string {
+	var out bytes.Buffer
+	out.WriteString(cf.Name.String())
+	if cf.Optional {
+		out.WriteString("?")
+	}
+	if cf.Default != nil {
+		out.WriteString(" be ")
+		out.WriteString(cf.Default.String())
+	}
+	if cf.Validator != nil {
+		out.WriteString(" if ")
+		out.WriteString(cf.Validator.String())
+	}
 	return out.String()
 }
 
+// ChestMethod is a function declared inside a chest statement. When it is
+// called through an instance, `self` is bound to that instance.
+type ChestMethod struct {
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (cm *ChestMethod) String() string {
+	return cm.Name.String() + " be " + cm.Function.String()
+}
+
 type ChestLiteral struct {
 	Token token.Token
-	Items map[*Identifier]Expression
+	Items []*ChestArgument // in source order
 }
 
 func (tl *ChestLiteral) expressionNode()      {}
@@ -418,8 +596,8 @@ func (tl *ChestLiteral) TokenLiteral() string { return tl.Token.Literal }
 func (tl *ChestLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for key, value := range tl.Items {
-		pairs = append(pairs, key.Value+":"+value.String())
+	for _, item := range tl.Items {
+		pairs = append(pairs, item.Name.Value+":"+item.Value.String())
 	}
 	out.WriteString("|")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -480,10 +658,11 @@ func (ca *ChestAccess) String() string {
 }
 
 type ChestFieldAssignment struct {
-	Token token.Token
-	Left  Expression
-	Field *Identifier
-	Value Expression
+	Token    token.Token
+	Left     Expression
+	Field    *Identifier
+	Operator string // "+", "-", "*" or "/" for compound forms like `c|gold +be 1`
+	Value    Expression
 }
 
 func (ca *ChestFieldAssignment) statementNode()       {}
@@ -494,8 +673,186 @@ func (ca *ChestFieldAssignment) String() string {
 	out.WriteString(ca.Left.String())
 	out.WriteString("|")
 	out.WriteString(ca.Field.String())
-	out.WriteString(" be ")
+	out.WriteString(" " + ca.Operator + "be ")
 	out.WriteString(ca.Value.String())
 	out.WriteString(".")
 	return out.String()
 }
+
+// MatchStatement runs the first arm whose pattern matches the subject, or the
+// ls block when none does.
+type MatchStatement struct {
+	Token     token.Token // The 'match' token
+	Subject   Expression
+	Arms      []*MatchArm
+	Alternate *BlockStatement
+}
+
+func (ms *MatchStatement) statementNode()       {}
+func (ms *MatchStatement) TokenLiteral() string { return ms.Token.Literal }
+func (ms *MatchStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("match ")
+	out.WriteString(ms.Subject.String())
+	out.WriteString(": ")
+	for _, arm := range ms.Arms {
+		out.WriteString(arm.String())
+	}
+	if ms.Alternate != nil {
+		out.WriteString("ls: ")
+		out.WriteString(ms.Alternate.String())
+	}
+	return out.String()
+}
+
+// MatchArm is `when pattern if guard: body`, the guard is optional.
+type MatchArm struct {
+	Token   token.Token // The 'when' token
+	Pattern Pattern
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+func (ma *MatchArm) TokenLiteral() string { return ma.Token.Literal }
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+	out.WriteString("when ")
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(": ")
+	out.WriteString(ma.Body.String())
+	return out.String()
+}
+
+// Pattern is matched against a value, binding names for the parts it
+// captures.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// WildcardPattern `_` matches anything and binds nothing.
+type WildcardPattern struct {
+	Token token.Token
+}
+
+func (wp *WildcardPattern) patternNode()         {}
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+func (wp *WildcardPattern) String() string       { return "_" }
+
+// BindingPattern matches anything and binds it to Name.
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (bp *BindingPattern) patternNode()         {}
+func (bp *BindingPattern) TokenLiteral() string { return bp.Name.TokenLiteral() }
+func (bp *BindingPattern) String() string       { return bp.Name.String() }
+
+// LiteralPattern matches values equal to an int, string or bool literal.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Value.TokenLiteral() }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+
+// ValuePattern `(expr)` matches values equal to the result of expr.
+type ValuePattern struct {
+	Token token.Token // The '(' token
+	Value Expression
+}
+
+func (vp *ValuePattern) patternNode()         {}
+func (vp *ValuePattern) TokenLiteral() string { return vp.Token.Literal }
+func (vp *ValuePattern) String() string       { return "(" + vp.Value.String() + ")" }
+
+// ArrayPattern matches arrays element by element. One of the elements may be
+// a RestPattern taking whatever the others leave over.
+type ArrayPattern struct {
+	Token    token.Token // The '[' token
+	Elements []Pattern
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// RestPattern `..name` captures the remaining elements or pairs, `.._`
+// ignores them.
+type RestPattern struct {
+	Token token.Token
+	Name  *Identifier
+}
+
+func (rp *RestPattern) patternNode()         {}
+func (rp *RestPattern) TokenLiteral() string { return rp.Token.Literal }
+func (rp *RestPattern) String() string       { return ".." + rp.Name.String() }
+
+// HashMapPattern matches hash maps holding every key it lists, with values
+// matching the key's pattern. Other keys are allowed and go to Rest if given.
+type HashMapPattern struct {
+	Token token.Token // The '{' token
+	Pairs []*HashMapPatternPair
+	Rest  *RestPattern
+}
+
+type HashMapPatternPair struct {
+	Key   Expression
+	Value Pattern
+}
+
+func (hp *HashMapPattern) patternNode()         {}
+func (hp *HashMapPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashMapPattern) String() string {
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	if hp.Rest != nil {
+		pairs = append(pairs, hp.Rest.String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// ChestPattern `Type|a, b: pattern|` matches chests of the chest type or one
+// extending it, `|a, b|` matches any chest with those fields. A field without
+// a pattern is bound to its own name.
+type ChestPattern struct {
+	Token  token.Token
+	Type   *Identifier // nil when the pattern has no chest type
+	Fields []*ChestFieldPattern
+}
+
+type ChestFieldPattern struct {
+	Name    *Identifier
+	Pattern Pattern // nil binds the field to Name
+}
+
+func (cp *ChestPattern) patternNode()         {}
+func (cp *ChestPattern) TokenLiteral() string { return cp.Token.Literal }
+func (cp *ChestPattern) String() string {
+	fields := []string{}
+	for _, f := range cp.Fields {
+		if f.Pattern == nil {
+			fields = append(fields, f.Name.String())
+		} else {
+			fields = append(fields, f.Name.String()+": "+f.Pattern.String())
+		}
+	}
+	typeName := ""
+	if cp.Type != nil {
+		typeName = cp.Type.String()
+	}
+	return typeName + "|" + strings.Join(fields, ", ") + "|"
+}
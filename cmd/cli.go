@@ -49,8 +49,8 @@ func main() {
 
 	ns := object.NewNamespace()
 	evaluated := evaluator.Eval(programTreeRoot, ns)
-	if evaluated.Type() != object.MT_OBJ {
-		writer.WriteOutput(evaluated.AsString())
+	if evaluated != nil && evaluated.Type() != object.MT_OBJ {
+		writer.WriteOutput(evaluator.Display(evaluated))
 	}
 	fmt.Print(writer.GetOutput())
 }
@@ -24,10 +24,10 @@ func evaluate(code string) {
 		}
 	}
 	evaluated := evaluator.Eval(program, ns)
-	if evaluated == evaluator.MT {
+	if evaluated == nil || evaluated == evaluator.MT {
 		return
 	}
-	writer.WriteOutput(evaluated.AsString())
+	writer.WriteOutput(evaluator.Display(evaluated))
 }
 
 func evalProgram(_ js.Value, args []js.Value) interface{} {
@@ -8,7 +8,7 @@ import (
 )
 
 func resolveBuiltin(id string) *object.Builtin {
-	builtin := &object.Builtin{}
+	builtin := &object.Builtin{Name: id}
 
 	switch id {
 	case "len":
@@ -23,12 +23,94 @@ func resolveBuiltin(id string) *object.Builtin {
 		builtin.Fn = insert
 	case "isMTValue":
 		builtin.Fn = isMT
+	case "type":
+		builtin.Fn = type_f
+	case "isType":
+		builtin.Fn = isType
+	case "isA":
+		builtin.Fn = isA
+	case "int":
+		builtin.Fn = int_f
+	case "str":
+		builtin.Fn = str_f
+	case "bool":
+		builtin.Fn = bool_f
+	case "same":
+		builtin.Fn = same
 	case "ahoy":
 		builtin.Fn = ahoy
+	case "repr":
+		builtin.Fn = repr
 	case "empty":
 		builtin.Fn = empty
 	case "maybe":
 		builtin.Fn = maybe
+	case "split":
+		builtin.Fn = split
+	case "join":
+		builtin.Fn = join
+	case "upper":
+		builtin.Fn = upper
+	case "lower":
+		builtin.Fn = lower
+	case "trim":
+		builtin.Fn = trim
+	case "replace":
+		builtin.Fn = replace
+	case "contains":
+		builtin.Fn = contains
+	case "startsWith":
+		builtin.Fn = startsWith
+	case "endsWith":
+		builtin.Fn = endsWith
+	case "find":
+		builtin.Fn = find
+	case "repeat":
+		builtin.Fn = repeat
+	case "pad":
+		builtin.Fn = pad
+	case "chars":
+		builtin.Fn = chars
+	case "map":
+		builtin.Fn = map_f
+	case "filter":
+		builtin.Fn = filter
+	case "reduce":
+		builtin.Fn = reduce
+	case "any":
+		builtin.Fn = any_f
+	case "all":
+		builtin.Fn = all
+	case "zip":
+		builtin.Fn = zip
+	case "enumerate":
+		builtin.Fn = enumerate
+	case "flatten":
+		builtin.Fn = flatten
+	case "reverse":
+		builtin.Fn = reverse
+	case "sort":
+		builtin.Fn = sort_f
+	case "keys":
+		builtin.Fn = keys
+	case "values":
+		builtin.Fn = values
+	case "items":
+		builtin.Fn = items
+	case "has":
+		builtin.Fn = has
+	case "delete":
+		builtin.Fn = delete_f
+	case "getOr":
+		builtin.Fn = getOr
+	case "merge":
+		builtin.Fn = merge
+	case "name":
+		builtin.Fn = name_f
+	case "arity":
+		builtin.Fn = arity
+	case "doc":
+		builtin.Fn = doc
 	default:
 		return nil
 	}
@@ -36,6 +118,87 @@ func resolveBuiltin(id string) *object.Builtin {
 	return builtin
 }
 
+var argOrdinals = []string{"first", "second", "third", "fourth"}
+
+// checkArgCount returns an error object unless min to max args were passed.
+func checkArgCount(args []object.Object, min, max int) object.Object {
+	if len(args) >= min && len(args) <= max {
+		return nil
+	}
+	if min == max {
+		return newEvaluationError("wrong number of arguments. got=%d, expected=%d",
+			len(args), min)
+	}
+	return newEvaluationError("wrong number of arguments. got=%d, expected=%d to %d",
+		len(args), min, max)
+}
+
+func argTypeError(name string, i int, expected object.ObjectType, got object.Object) object.Object {
+	return newEvaluationError("%s argument to `%s` must be %s, got %s",
+		argOrdinals[i], name, expected, got.Type())
+}
+
+func stringArg(name string, args []object.Object, i int) (string, object.Object) {
+	str, ok := args[i].(*object.String)
+	if !ok {
+		return "", argTypeError(name, i, object.STRING_OBJ, args[i])
+	}
+	return str.Value, nil
+}
+
+func stringArgs(name string, args []object.Object) ([]string, object.Object) {
+	strs := make([]string, len(args))
+	for i := range args {
+		str, err := stringArg(name, args, i)
+		if err != nil {
+			return nil, err
+		}
+		strs[i] = str
+	}
+	return strs, nil
+}
+
+func arrayArg(name string, args []object.Object, i int) (*object.Array, object.Object) {
+	arr, ok := args[i].(*object.Array)
+	if !ok {
+		return nil, argTypeError(name, i, object.ARRAY_OBJ, args[i])
+	}
+	return arr, nil
+}
+
+func hashMapArg(name string, args []object.Object, i int) (*object.HashMap, object.Object) {
+	hashMap, ok := args[i].(*object.HashMap)
+	if !ok {
+		return nil, argTypeError(name, i, object.HASHMAP_OBJ, args[i])
+	}
+	return hashMap, nil
+}
+
+func hashableArg(args []object.Object, i int) (object.Hashable, object.Object) {
+	key, ok := object.AsHashable(args[i])
+	if !ok {
+		return nil, newEvaluationError("Object not hashable. Type=%s", args[i].Type())
+	}
+	return key, nil
+}
+
+func functionArg(name string, args []object.Object, i int) (object.Object, object.Object) {
+	switch args[i].(type) {
+	case *object.Function, *object.Builtin:
+		return args[i], nil
+	default:
+		return nil, argTypeError(name, i, object.FUNCTION_OBJ, args[i])
+	}
+}
+
+func intArg(name string, args []object.Object, i int) (int64, object.Object) {
+	integer, ok := args[i].(*object.Int)
+	if !ok {
+		return 0, argTypeError(name, i, object.INT_OBJ, args[i])
+	}
+	return integer.Value, nil
+}
+
 /*
 func name(args ...object.Object) object.Object {
 	if len(args) != 0 {
@@ -54,31 +217,33 @@ func name(args ...object.Object) object.Object {
 */
 //arrays
 func len_f(args ...object.Object) object.Object {
-	if len(args) != 1 {
-		return newEvaluationError("wrong number of args. got=%d, expected=1",
-			len(args))
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
 	}
 
-	switch arg := args[0].(type) {
-	case *object.String:
-		return nativeIntToIntObj(int64(len(arg.Value)))
-	case *object.Array:
-		return nativeIntToIntObj(int64(len(arg.Elements)))
-	default:
+	if handler, ok := chestMethod(args[0], "len"); ok {
+		result := callFunc(handler, []object.Object{})
+		if !object.IsError(result) && result.Type() != object.INT_OBJ {
+			return newEvaluationError("len of %s must give INT, got %s", typeName(args[0]), result.Type())
+		}
+		return result
+	}
+	sized, ok := args[0].(object.Sized)
+	if !ok {
 		return newEvaluationError("argument to `len` not supported, got %s",
 			args[0].Type())
 	}
+	return nativeIntToIntObj(int64(sized.Len()))
 }
 
 func empty(args ...object.Object) object.Object {
-	if len(args) != 1 {
-		return newEvaluationError("wrong number of args. got=%d, expected=1",
-			len(args))
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
 	}
 
 	switch arg := args[0].(type) {
 	case *object.HashMap:
-		arg.MP = make(map[object.HashKey]object.KVP)
+		arg.Clear()
 		return arg
 	case *object.Array:
 		arg.Elements = make([]object.Object, 0)
@@ -90,9 +255,8 @@ func empty(args ...object.Object) object.Object {
 }
 
 func peek(args ...object.Object) object.Object {
-	if len(args) != 1 {
-		return newEvaluationError("wrong number of arguments. got=%d, expected=1",
-			len(args))
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
 	}
 	if args[0].Type() != object.ARRAY_OBJ {
 		return newEvaluationError("argument to `peek` must be ARRAY, got %s",
@@ -106,9 +270,8 @@ func peek(args ...object.Object) object.Object {
 }
 
 func pop(args ...object.Object) object.Object {
-	if len(args) != 1 {
-		return newEvaluationError("wrong number of arguments. got=%d, expected=1",
-			len(args))
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
 	}
 	if args[0].Type() != object.ARRAY_OBJ {
 		return newEvaluationError("argument to `pop` must be ARRAY, got %s",
@@ -124,9 +287,8 @@ func pop(args ...object.Object) object.Object {
 }
 
 func push(args ...object.Object) object.Object {
-	if len(args) != 2 {
-		return newEvaluationError("wrong number of arguments. got=%d, expected=2",
-			len(args))
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
 	}
 	if args[0].Type() != object.ARRAY_OBJ {
 		return newEvaluationError("first argument to `push` must be ARRAY, got %s",
@@ -139,9 +301,8 @@ func push(args ...object.Object) object.Object {
 }
 
 func insert(args ...object.Object) object.Object {
-	if len(args) != 3 {
-		return newEvaluationError("wrong number of arguments. got=%d, expected=3",
-			len(args))
+	if err := checkArgCount(args, 3, 3); err != nil {
+		return err
 	}
 	if args[0].Type() != object.ARRAY_OBJ {
 		return newEvaluationError("first argument to `insert` must be ARRAY, got %s",
@@ -162,17 +323,24 @@ func insert(args ...object.Object) object.Object {
 }
 
 func isMT(args ...object.Object) object.Object {
-	if len(args) != 1 {
-		return newEvaluationError("wrong number of arguments. got=%d, expected=1",
-			len(args))
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
 	}
 	return nativeBoolToBoolObj(args[0] == MT)
 }
 
+// same compares by reference, unlike `=` which compares arrays, hashmaps and
+// chests by content.
+func same(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	return nativeBoolToBoolObj(args[0] == args[1])
+}
+
 func maybe(args ...object.Object) object.Object {
-	if len(args) > 0 {
-		return newEvaluationError("wrong number of arguments. got=%d, expected=0",
-			len(args))
+	if err := checkArgCount(args, 0, 0); err != nil {
+		return err
 	}
 	return nativeBoolToBoolObj(rand.Intn(2) == 0)
 }
@@ -187,10 +355,21 @@ func ahoy(args ...object.Object) object.Object {
 */
 
 func ahoy(args ...object.Object) object.Object {
-	var str string
 	for _, arg := range args {
-		str = arg.AsString() + "\n"
-		writer.WriteOutput(str)
+		str, err := displayString(arg)
+		if err != nil {
+			return err
+		}
+		writer.WriteOutput(str + "\n")
 	}
 	return MT
 }
+
+// repr gives the source-like form of a value, quoting strings even at the top
+// level, where ahoy prints them as they are.
+func repr(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	return nativeStringToStringObj(object.Repr(args[0]))
+}
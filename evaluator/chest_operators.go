@@ -0,0 +1,146 @@
+package evaluator
+
+import "pir-interpreter/object"
+
+// Chest types overload operators by declaring methods with these names. The
+// handler is called on the left operand with the right one as its argument.
+var infixHandlers = map[string]string{
+	"+":   "plus",
+	"-":   "minus",
+	"*":   "times",
+	"/":   "divide",
+	"mod": "modulo",
+	"<":   "less",
+	">":   "more",
+	"<=":  "atMost",
+	">=":  "atLeast",
+}
+
+var prefixHandlers = map[string]string{
+	"-": "negate",
+	"!": "not",
+}
+
+// chestMethod finds a method on the chest type of obj, bound to obj.
+func chestMethod(obj object.Object, name string) (*object.Function, bool) {
+	chest, ok := obj.(*object.Chest)
+	if !ok || chest.ChestType == nil {
+		return nil, false
+	}
+	method, ok := chest.ChestType.Methods[name]
+	if !ok {
+		return nil, false
+	}
+	return bindMethod(method, chest), true
+}
+
+// evalChestInfixOperator reports false when neither operand overloads the
+// operator, so the built-in rules apply. Equality is symmetric: if only the
+// right operand defines equals it is asked instead.
+func evalChestInfixOperator(left object.Object, operator string, right object.Object) (object.Object, bool) {
+	if operator == "=" || operator == "<>" {
+		owner, other := left, right
+		handler, ok := chestMethod(owner, "equals")
+		if !ok {
+			owner, other = right, left
+			handler, ok = chestMethod(owner, "equals")
+		}
+		if !ok {
+			return nil, false
+		}
+		equal, err := callBoolHandler(handler, "equals", owner, other)
+		if err != nil {
+			return err, true
+		}
+		if operator == "<>" {
+			return nativeBoolToBoolObj(!equal), true
+		}
+		return nativeBoolToBoolObj(equal), true
+	}
+
+	name, ok := infixHandlers[operator]
+	if !ok {
+		return nil, false
+	}
+	handler, ok := chestMethod(left, name)
+	if !ok {
+		return nil, false
+	}
+	switch operator {
+	case "<", ">", "<=", ">=":
+		result, err := callBoolHandler(handler, name, left, right)
+		if err != nil {
+			return err, true
+		}
+		return nativeBoolToBoolObj(result), true
+	default:
+		return callFunc(handler, []object.Object{right}), true
+	}
+}
+
+func evalChestPrefixOperator(operator string, operand object.Object) (object.Object, bool) {
+	name, ok := prefixHandlers[operator]
+	if !ok {
+		return nil, false
+	}
+	handler, ok := chestMethod(operand, name)
+	if !ok {
+		return nil, false
+	}
+	return callFunc(handler, []object.Object{}), true
+}
+
+func callBoolHandler(handler *object.Function, name string, owner, arg object.Object) (bool, object.Object) {
+	result := callFunc(handler, []object.Object{arg})
+	if object.IsError(result) {
+		return false, result
+	}
+	b, ok := result.(*object.Bool)
+	if !ok {
+		return false, newEvaluationError("%s of %s must give BOOL, got %s", name, typeName(owner), result.Type())
+	}
+	return b.Value, nil
+}
+
+// displayString is how ahoy, str and f-strings turn a value into text: strings
+// as they are, chests at any level through their ahoy method. Unlike AsString
+// it reports errors raised by an ahoy method.
+func displayString(obj object.Object) (string, object.Object) {
+	if s, ok := obj.(*object.String); ok {
+		return s.Value, nil
+	}
+	var err object.Object
+	str := object.Format(obj, func(chest *object.Chest) (string, bool) {
+		handler, ok := chestMethod(chest, "ahoy")
+		if !ok || err != nil {
+			return "", false
+		}
+		var s string
+		s, err = callStrHandler(handler, chest)
+		return s, err == nil
+	})
+	return str, err
+}
+
+// Display is the text the REPL and the CLI show for the value a program
+// ends with, the same ahoy would print. An ahoy method that fails shows its
+// error instead.
+func Display(obj object.Object) string {
+	str, err := displayString(obj)
+	if err != nil {
+		return err.AsString()
+	}
+	return str
+}
+
+func callStrHandler(handler *object.Function, owner object.Object) (string, object.Object) {
+	result := callFunc(handler, []object.Object{})
+	if object.IsError(result) {
+		return "", result
+	}
+	s, ok := result.(*object.String)
+	if !ok {
+		return "", newEvaluationError("ahoy of %s must give STRING, got %s", typeName(owner), result.Type())
+	}
+	return s.Value, nil
+}
@@ -0,0 +1,307 @@
+package evaluator
+
+import (
+	"pir-interpreter/object"
+	"slices"
+	"sort"
+)
+
+func map_f(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	arr, fn, err := arrayAndFunctionArgs("map", args)
+	if err != nil {
+		return err
+	}
+	elements := make([]object.Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		result := callFunc(fn, []object.Object{el})
+		if object.IsError(result) {
+			return result
+		}
+		elements[i] = result
+	}
+	return &object.Array{Elements: elements}
+}
+
+func filter(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	arr, fn, err := arrayAndFunctionArgs("filter", args)
+	if err != nil {
+		return err
+	}
+	elements := []object.Object{}
+	for _, el := range arr.Elements {
+		keep, err := callPredicate("filter", fn, el)
+		if err != nil {
+			return err
+		}
+		if keep {
+			elements = append(elements, el)
+		}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// reduce folds the array with fn(acc, el). Without an initial value the
+// first element is used.
+func reduce(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 3); err != nil {
+		return err
+	}
+	arr, fn, err := arrayAndFunctionArgs("reduce", args)
+	if err != nil {
+		return err
+	}
+	elements := arr.Elements
+	var acc object.Object
+	if len(args) == 3 {
+		acc = args[2]
+	} else {
+		if len(elements) == 0 {
+			return newEvaluationError("reduce of empty ARRAY with no initial value")
+		}
+		acc, elements = elements[0], elements[1:]
+	}
+	for _, el := range elements {
+		acc = callFunc(fn, []object.Object{acc, el})
+		if object.IsError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+func any_f(args ...object.Object) object.Object {
+	return testElements("any", true, args)
+}
+
+func all(args ...object.Object) object.Object {
+	return testElements("all", false, args)
+}
+
+// testElements stops at the first element whose test gives stopAt. Without a
+// predicate the elements themselves must be BOOLs.
+func testElements(name string, stopAt bool, args []object.Object) object.Object {
+	if err := checkArgCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := arrayArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	var fn object.Object
+	if len(args) == 2 {
+		if fn, err = functionArg(name, args, 1); err != nil {
+			return err
+		}
+	}
+	for _, el := range arr.Elements {
+		var result bool
+		if fn != nil {
+			if result, err = callPredicate(name, fn, el); err != nil {
+				return err
+			}
+		} else {
+			b, ok := el.(*object.Bool)
+			if !ok {
+				return newEvaluationError("elements passed to `%s` must be BOOL, got %s", name, el.Type())
+			}
+			result = b.Value
+		}
+		if result == stopAt {
+			return nativeBoolToBoolObj(stopAt)
+		}
+	}
+	return nativeBoolToBoolObj(!stopAt)
+}
+
+func findElement(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunctionArgs("find", args)
+	if err != nil {
+		return err
+	}
+	for _, el := range arr.Elements {
+		found, err := callPredicate("find", fn, el)
+		if err != nil {
+			return err
+		}
+		if found {
+			return el
+		}
+	}
+	return MT
+}
+
+// zip pairs up elements by position, stopping at the shortest array.
+func zip(args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return newEvaluationError("wrong number of arguments. got=0, expected at least 1")
+	}
+	arrays := make([]*object.Array, len(args))
+	shortest := -1
+	for i := range args {
+		arr, ok := args[i].(*object.Array)
+		if !ok {
+			return newEvaluationError("arguments to `zip` must be ARRAY, got %s", args[i].Type())
+		}
+		arrays[i] = arr
+		if shortest < 0 || len(arr.Elements) < shortest {
+			shortest = len(arr.Elements)
+		}
+	}
+	elements := make([]object.Object, shortest)
+	for i := range elements {
+		tuple := make([]object.Object, len(arrays))
+		for j, arr := range arrays {
+			tuple[j] = arr.Elements[i]
+		}
+		elements[i] = &object.Array{Elements: tuple}
+	}
+	return &object.Array{Elements: elements}
+}
+
+func enumerate(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := arrayArg("enumerate", args, 0)
+	if err != nil {
+		return err
+	}
+	elements := make([]object.Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		elements[i] = &object.Array{Elements: []object.Object{nativeIntToIntObj(int64(i)), el}}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// flatten unnests arrays depth levels deep, one level by default.
+func flatten(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := arrayArg("flatten", args, 0)
+	if err != nil {
+		return err
+	}
+	depth := int64(1)
+	if len(args) == 2 {
+		if depth, err = intArg("flatten", args, 1); err != nil {
+			return err
+		}
+	}
+	return &object.Array{Elements: flattenElements(arr.Elements, depth)}
+}
+
+func flattenElements(elements []object.Object, depth int64) []object.Object {
+	flat := []object.Object{}
+	for _, el := range elements {
+		if inner, ok := el.(*object.Array); ok && depth > 0 {
+			flat = append(flat, flattenElements(inner.Elements, depth-1)...)
+		} else {
+			flat = append(flat, el)
+		}
+	}
+	return flat
+}
+
+func reverse(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	switch arg := args[0].(type) {
+	case *object.Array:
+		elements := slices.Clone(arg.Elements)
+		slices.Reverse(elements)
+		return &object.Array{Elements: elements}
+	case *object.String:
+		runes := []rune(arg.Value)
+		slices.Reverse(runes)
+		return nativeStringToStringObj(string(runes))
+	default:
+		return newEvaluationError("argument to `reverse` not supported, got %s",
+			args[0].Type())
+	}
+}
+
+// sort_f returns a stably sorted copy of the array. The optional comparator
+// gives ay when its first argument belongs before its second, otherwise
+// elements are ordered with <.
+func sort_f(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := arrayArg("sort", args, 0)
+	if err != nil {
+		return err
+	}
+	less := func(a, b object.Object) (bool, object.Object) {
+		result := evalInfixExpression(a, "<", b)
+		if object.IsError(result) {
+			return false, result
+		}
+		return result == AY, nil
+	}
+	if len(args) == 2 {
+		fn, err := functionArg("sort", args, 1)
+		if err != nil {
+			return err
+		}
+		less = func(a, b object.Object) (bool, object.Object) {
+			return callPredicate("sort", fn, a, b)
+		}
+	} else {
+		for _, el := range arr.Elements {
+			if el.Type() != arr.Elements[0].Type() {
+				return newEvaluationError("cannot compare %s and %s in `sort`",
+					arr.Elements[0].Type(), el.Type())
+			}
+		}
+	}
+
+	elements := slices.Clone(arr.Elements)
+	var sortErr object.Object
+	sort.SliceStable(elements, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+		result, err := less(elements[i], elements[j])
+		if err != nil {
+			sortErr = err
+		}
+		return result
+	})
+	if sortErr != nil {
+		return sortErr
+	}
+	return &object.Array{Elements: elements}
+}
+
+func arrayAndFunctionArgs(name string, args []object.Object) (*object.Array, object.Object, object.Object) {
+	arr, err := arrayArg(name, args, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fn, err := functionArg(name, args, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return arr, fn, nil
+}
+
+// callPredicate calls fn and requires it to give a BOOL.
+func callPredicate(name string, fn object.Object, args ...object.Object) (bool, object.Object) {
+	result := callFunc(fn, args)
+	if object.IsError(result) {
+		return false, result
+	}
+	b, ok := result.(*object.Bool)
+	if !ok {
+		return false, newEvaluationError("function passed to `%s` must give BOOL, got %s", name, result.Type())
+	}
+	return b.Value, nil
+}
@@ -2,9 +2,13 @@ package evaluator
 
 import (
 	"fmt"
+	"maps"
 	"pir-interpreter/ast"
 	"pir-interpreter/object"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 var (
@@ -42,18 +46,24 @@ func Eval(node ast.Node, ns *object.Namespace) object.Object {
 		return evalYarStatementNode(node, ns)
 	case *ast.ForStatement:
 		return evalForStatementNode(node, ns)
+	case *ast.ForEachStatement:
+		return evalForEachStatementNode(node, ns)
 	case *ast.FunctionLiteral:
 		return evalFuncLiteral(node, ns)
 	case *ast.CallExpression:
 		return evalFuncCallNode(node, ns)
 	case *ast.IndexExpression:
 		return evalIndexExpressionNode(node, ns)
+	case *ast.SliceExpression:
+		return evalSliceExpressionNode(node, ns)
 	case *ast.IndexAssignment:
 		return evalIndexAssignmentNode(node, ns)
 	case *ast.ArrayLiteral:
 		return evalArrayLiteralNode(node, ns)
 	case *ast.StringLiteral:
 		return nativeStringToStringObj(node.Value)
+	case *ast.InterpolatedString:
+		return evalInterpolatedStringNode(node, ns)
 	case *ast.HashMapLiteral:
 		return evalHashMapLiteralNode(node, ns)
 	case *ast.ChestLiteral:
@@ -66,6 +76,10 @@ func Eval(node ast.Node, ns *object.Namespace) object.Object {
 		return evalChestStatementNode(node, ns)
 	case *ast.ChestFieldAssignment:
 		return evalChestFieldAssignmentNode(node, ns)
+	case *ast.FlagStatement:
+		return evalFlagStatementNode(node, ns)
+	case *ast.MatchStatement:
+		return evalMatchStatementNode(node, ns)
 	case *ast.BreakStatement:
 		return BREAK
 	}
@@ -81,7 +95,9 @@ func evalIndexAssignmentNode(node *ast.IndexAssignment, ns *object.Namespace) ob
 	if object.IsError(index) {
 		return index
 	}
-	value := Eval(node.Value, ns)
+	value := evalAssignedValue(node.Operator, func() object.Object {
+		return evalIndexExpression(left, index)
+	}, node.Value, ns)
 	if object.IsError(value) {
 		return value
 	}
@@ -91,6 +107,12 @@ func evalIndexAssignmentNode(node *ast.IndexAssignment, ns *object.Namespace) ob
 }
 
 func evalIndexAssignment(left, index, val object.Object) object.Object {
+	if handler, ok := chestMethod(left, "setAt"); ok {
+		if result := callFunc(handler, []object.Object{index, val}); object.IsError(result) {
+			return result
+		}
+		return MT
+	}
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INT_OBJ:
 		return evalArrayIndexAssignment(left, index, val)
@@ -103,10 +125,9 @@ func evalIndexAssignment(left, index, val object.Object) object.Object {
 
 func evalArrayIndexAssignment(left, index, val object.Object) object.Object {
 	arr := left.(*object.Array)
-	i := index.(*object.Int).Value
-	mx := int64(len(arr.Elements) - 1)
-	if i < 0 || i > mx {
-		return newEvaluationError("index out of bounds. len=%d, index=%d", len(arr.Elements), i)
+	i, ok := object.ResolveIndex(index.(*object.Int).Value, len(arr.Elements))
+	if !ok {
+		return newEvaluationError("index out of bounds. len=%d, index=%d", len(arr.Elements), index.(*object.Int).Value)
 	}
 	arr.Elements[i] = val
 	return MT
@@ -114,37 +135,38 @@ func evalArrayIndexAssignment(left, index, val object.Object) object.Object {
 
 func evalHashMapIndexAssignment(left, key, val object.Object) object.Object {
 	if hashMap, ok := left.(*object.HashMap); ok {
-		preHashKey, ok := key.(object.Hashable)
+		preHashKey, ok := object.AsHashable(key)
 		if !ok {
-			return newEvaluationError("Object not hashable: type=%T", key)
+			return newEvaluationError("Object not hashable. Type=%s", key.Type())
 		}
-		hashKey := preHashKey.Hash()
-		hashMap.MP[hashKey] = object.KVP{Key: key, Value: val}
+		hashMap.Set(preHashKey, val)
 	}
 	return MT
 }
 
 func evalHashMapLiteralNode(node *ast.HashMapLiteral, ns *object.Namespace) object.Object {
-	hm := make(map[object.HashKey]object.KVP)
-	for keyNode, valueNode := range node.MP {
-		key := Eval(keyNode, ns)
+	hm := object.NewHashMap()
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, ns)
 		if object.IsError(key) {
 			return key
 		}
-		preHashKey, ok := key.(object.Hashable)
+		preHashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newEvaluationError("Object not hashable. Type=%s", key.Type())
 		}
+		if _, exists := hm.Get(preHashKey); exists {
+			return newEvaluationError("duplicate key in hashmap literal: %s", key.AsString())
+		}
 
-		value := Eval(valueNode, ns)
+		value := Eval(pair.Value, ns)
 
 		if object.IsError(value) {
 			return value
 		}
-		hashKey := preHashKey.Hash()
-		hm[hashKey] = object.KVP{Key: key, Value: value}
+		hm.Set(preHashKey, value)
 	}
-	return &object.HashMap{MP: hm}
+	return hm
 }
 
 func evalIndexExpressionNode(node *ast.IndexExpression, ns *object.Namespace) object.Object {
@@ -160,38 +182,73 @@ func evalIndexExpressionNode(node *ast.IndexExpression, ns *object.Namespace) ob
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
-	switch {
-	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INT_OBJ:
-		return evalArrayIndexExpression(left, index)
-	case left.Type() == object.HASHMAP_OBJ:
-		return evalHashMapIndexExpression(left, index)
-	default:
-		return newEvaluationError("index operator not supported: %s", left.Type())
+	if handler, ok := chestMethod(left, "at"); ok {
+		return callFunc(handler, []object.Object{index})
 	}
-}
-
-func evalHashMapIndexExpression(hash, index object.Object) object.Object {
-	hashObject := hash.(*object.HashMap)
-	key, ok := index.(object.Hashable)
+	indexable, ok := left.(object.Indexable)
 	if !ok {
-		return newEvaluationError("Object not hashable. Type=%s", index.Type())
+		return newEvaluationError("index operator not supported: %s", typeName(left))
 	}
-	kvp, ok := hashObject.MP[key.Hash()]
-	if !ok {
+	result := indexable.Index(index)
+	if result == nil {
 		return MT
 	}
-	return kvp.Value
+	return result
+}
+
+func evalSliceExpressionNode(node *ast.SliceExpression, ns *object.Namespace) object.Object {
+	left := Eval(node.Left, ns)
+	if object.IsError(left) {
+		return left
+	}
+	bounds := []object.Object{nil, nil}
+	for i, exp := range []ast.Expression{node.Start, node.End} {
+		if exp == nil {
+			continue
+		}
+		bound := Eval(exp, ns)
+		if object.IsError(bound) {
+			return bound
+		}
+		if bound.Type() != object.INT_OBJ {
+			return newEvaluationError("slice bounds must be INT, got %s", bound.Type())
+		}
+		bounds[i] = bound
+	}
 
+	switch left := left.(type) {
+	case *object.Array:
+		lo, hi := resolveSliceBounds(bounds[0], bounds[1], len(left.Elements))
+		elements := make([]object.Object, hi-lo)
+		copy(elements, left.Elements[lo:hi])
+		return &object.Array{Elements: elements}
+	case *object.String:
+		runes := []rune(left.Value)
+		lo, hi := resolveSliceBounds(bounds[0], bounds[1], len(runes))
+		return nativeStringToStringObj(string(runes[lo:hi]))
+	default:
+		return newEvaluationError("slice operator not supported: %s", left.Type())
+	}
 }
 
-func evalArrayIndexExpression(array, index object.Object) object.Object {
-	arr := array.(*object.Array)
-	i := index.(*object.Int).Value
-	mx := int64(len(arr.Elements) - 1)
-	if i < 0 || i > mx {
-		return newEvaluationError("index out of bounds. len=%d, index=%d", len(arr.Elements), i)
+// resolveSliceBounds turns optional, possibly negative bounds into a valid
+// range. Out of range bounds are clamped rather than reported.
+func resolveSliceBounds(start, end object.Object, length int) (int, int) {
+	clamp := func(bound object.Object, fallback int) int {
+		if bound == nil {
+			return fallback
+		}
+		i := bound.(*object.Int).Value
+		if i < 0 {
+			i += int64(length)
+		}
+		return int(max(0, min(i, int64(length))))
 	}
-	return arr.Elements[i]
+	lo, hi := clamp(start, 0), clamp(end, length)
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi
 }
 
 func evalArrayLiteralNode(node *ast.ArrayLiteral, ns *object.Namespace) object.Object {
@@ -206,28 +263,93 @@ func nativeStringToStringObj(str string) object.Object {
 	return &object.String{Value: str}
 }
 
+func evalInterpolatedStringNode(node *ast.InterpolatedString, ns *object.Namespace) object.Object {
+	var out strings.Builder
+	for _, part := range node.Parts {
+		val := Eval(part, ns)
+		if object.IsError(val) {
+			return val
+		}
+		str, err := displayString(val)
+		if err != nil {
+			return err
+		}
+		out.WriteString(str)
+	}
+	return nativeStringToStringObj(out.String())
+}
+
 func evalChestLiteralNode(node *ast.ChestLiteral, ns *object.Namespace) object.Object {
-	items := make(map[string]object.Object)
-	for id, expr := range node.Items {
-		val := Eval(expr, ns)
+	chest := object.NewChest()
+	for _, item := range node.Items {
+		val := Eval(item.Value, ns)
 		if object.IsError(val) {
 			return val
 		}
-		items[id.Value] = val
+		chest.Set(item.Name.Value, val)
 	}
-	return &object.Chest{Items: items}
+	return chest
 }
 
 func evalChestStatementNode(node *ast.ChestStatement, ns *object.Namespace) object.Object {
-	fields := make([]string, len(node.FieldList))
-	for i, f := range node.FieldList {
-		fields[i] = f.Value
+	ct := &object.ChestType{Name: node.Name.Value, Methods: make(map[string]*object.Function)}
+	if node.Parent != nil {
+		parentObj := Eval(node.Parent, ns)
+		if object.IsError(parentObj) {
+			return parentObj
+		}
+		parent, ok := parentObj.(*object.ChestType)
+		if !ok {
+			return newEvaluationError("%s cannot extend %s: not a chest type", ct.Name, parentObj.Type())
+		}
+		ct.Parent = parent
+		ct.Fields = slices.Clone(parent.Fields)
+		maps.Copy(ct.Methods, parent.Methods)
+	}
+	for _, f := range node.FieldList {
+		field := &object.ChestField{Name: f.Name.Value, Default: f.Default, NS: ns, Optional: f.Optional}
+		if f.Validator != nil {
+			validator := Eval(f.Validator, ns)
+			if object.IsError(validator) {
+				return validator
+			}
+			if validator.Type() != object.FUNCTION_OBJ && validator.Type() != object.BUILTIN_OBJ {
+				return newEvaluationError("validator for field %s of %s must be a function, got %s",
+					field.Name, ct.Name, validator.Type())
+			}
+			field.Validator = validator
+		}
+		// A redeclared field keeps its place, but nothing else of the inherited one.
+		i := slices.IndexFunc(ct.Fields, func(inherited *object.ChestField) bool {
+			return inherited.Name == field.Name
+		})
+		if i >= 0 {
+			ct.Fields[i] = field
+		} else {
+			ct.Fields = append(ct.Fields, field)
+		}
+	}
+	for _, m := range node.Methods {
+		ct.Methods[m.Name.Value] = Eval(m.Function, ns).(*object.Function)
+	}
+	for _, field := range ct.Fields {
+		if _, ok := ct.Methods[field.Name]; ok {
+			return newEvaluationError("method %s of %s clashes with a field", field.Name, ct.Name)
+		}
 	}
-	ct := &object.ChestType{Fields: fields}
 	ns.Set(node.Name.Value, ct)
 	return MT
 }
 
+func evalFlagStatementNode(node *ast.FlagStatement, ns *object.Namespace) object.Object {
+	ft := &object.FlagType{Name: node.Name.Value}
+	for i, m := range node.Members {
+		ft.Members = append(ft.Members, &object.Flag{FlagType: ft, Name: m.Value, Ordinal: i})
+	}
+	ns.Set(node.Name.Value, ft)
+	return MT
+}
+
 func evalChestInstantiationNode(node *ast.ChestInstantiation, ns *object.Namespace) object.Object {
 	chestObj := Eval(node.Chest, ns)
 	if object.IsError(chestObj) {
@@ -237,46 +359,100 @@ func evalChestInstantiationNode(node *ast.ChestInstantiation, ns *object.Namespa
 	if !ok {
 		return newEvaluationError("not a chest type: %s", chestObj.Type())
 	}
+	items := make(map[string]object.Object)
 	if len(node.NamedArgs) > 0 {
-		if len(node.NamedArgs) != len(chestType.Fields) {
-			return newEvaluationError("wrong number of fields. expected=%d, got=%d", len(chestType.Fields), len(node.NamedArgs))
-		}
-		items := make(map[string]object.Object)
 		for _, arg := range node.NamedArgs {
 			val := Eval(arg.Value, ns)
 			if object.IsError(val) {
 				return val
 			}
 			name := arg.Name.Value
-			found := false
-			for _, f := range chestType.Fields {
-				if f == name {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return newEvaluationError("unknown field: %s", name)
+			if _, ok := chestType.Field(name); !ok {
+				return unknownFieldError(chestType, name)
 			}
 			items[name] = val
 		}
-		if len(items) != len(chestType.Fields) {
-			return newEvaluationError("wrong number of fields. expected=%d, got=%d", len(chestType.Fields), len(items))
+	} else {
+		args := evalExpressions(node.Arguments, ns)
+		if len(args) == 1 && object.IsError(args[0]) {
+			return args[0]
+		}
+		if err := checkChestArgCount(chestType, len(args)); err != nil {
+			return err
+		}
+		for i, val := range args {
+			items[chestType.Fields[i].Name] = val
 		}
-		return &object.Chest{Items: items}
 	}
-	args := evalExpressions(node.Arguments, ns)
-	if len(args) == 1 && object.IsError(args[0]) {
-		return args[0]
+
+	chest := object.NewChest()
+	chest.ChestType = chestType
+	for _, field := range chestType.Fields {
+		val, ok := items[field.Name]
+		if !ok {
+			val = defaultFieldValue(chestType, field)
+			if object.IsError(val) {
+				return val
+			}
+		}
+		if err := validateField(chestType, field, val); err != nil {
+			return err
+		}
+		chest.Set(field.Name, val)
 	}
-	if len(args) != len(chestType.Fields) {
-		return newEvaluationError("wrong number of fields. expected=%d, got=%d", len(chestType.Fields), len(args))
+	return chest
+}
+
+// checkChestArgCount checks positional arguments, which can leave out the
+// trailing fields that have a default or are optional.
+func checkChestArgCount(chestType *object.ChestType, got int) object.Object {
+	min := 0
+	for i, field := range chestType.Fields {
+		if field.Default == nil && !field.Optional {
+			min = i + 1
+		}
 	}
-	items := make(map[string]object.Object)
-	for i, name := range chestType.Fields {
-		items[name] = args[i]
+	max := len(chestType.Fields)
+	if got >= min && got <= max {
+		return nil
+	}
+	if min == max {
+		return newEvaluationError("wrong number of fields for %s. expected=%d, got=%d",
+			chestType.Name, max, got)
+	}
+	return newEvaluationError("wrong number of fields for %s. expected=%d to %d, got=%d",
+		chestType.Name, min, max, got)
+}
+
+func defaultFieldValue(chestType *object.ChestType, field *object.ChestField) object.Object {
+	switch {
+	case field.Default != nil:
+		return Eval(field.Default, field.NS)
+	case field.Optional:
+		return MT
+	default:
+		return newEvaluationError("missing field for %s: %s", chestType.Name, field.Name)
 	}
-	return &object.Chest{Items: items}
+}
+
+func validateField(chestType *object.ChestType, field *object.ChestField, val object.Object) object.Object {
+	if field.Validator == nil {
+		return nil
+	}
+	result := callFunc(field.Validator, []object.Object{val})
+	if object.IsError(result) {
+		return result
+	}
+	valid, ok := result.(*object.Bool)
+	if !ok {
+		return newEvaluationError("validator for field %s of %s must give BOOL, got %s",
+			field.Name, chestType.Name, result.Type())
+	}
+	if !valid.Value {
+		return newEvaluationError("invalid value for field %s of %s: %s",
+			field.Name, chestType.Name, object.Repr(val))
+	}
+	return nil
 }
 
 func evalChestAccessNode(node *ast.ChestAccess, ns *object.Namespace) object.Object {
@@ -284,14 +460,67 @@ func evalChestAccessNode(node *ast.ChestAccess, ns *object.Namespace) object.Obj
 	if object.IsError(left) {
 		return left
 	}
+	return evalChestAccess(left, node.Field.Value)
+}
+
+func evalChestAccess(left object.Object, name string) object.Object {
+	if flagType, ok := left.(*object.FlagType); ok {
+		return flagMember(flagType, name)
+	}
 	chest, ok := left.(*object.Chest)
 	if !ok {
 		return newEvaluationError("not a chest: %s", left.Type())
 	}
-	if val, ok := chest.Items[node.Field.Value]; ok {
+	if val, ok := chest.Items[name]; ok {
 		return val
 	}
-	return MT
+	if chest.ChestType == nil {
+		return MT
+	}
+	if method, ok := chest.ChestType.Methods[name]; ok {
+		return bindMethod(method, chest)
+	}
+	return unknownFieldError(chest.ChestType, name)
+}
+
+func flagMember(flagType *object.FlagType, name string) object.Object {
+	if member, ok := flagType.Member(name); ok {
+		return member
+	}
+	names := make([]string, len(flagType.Members))
+	for i, m := range flagType.Members {
+		names[i] = m.Name
+	}
+	if suggestion := closestName(name, names); suggestion != "" {
+		return newEvaluationError("unknown member for %s: %s (did you mean %s?)", flagType.Name, name, suggestion)
+	}
+	return newEvaluationError("unknown member for %s: %s", flagType.Name, name)
+}
+
+// unknownFieldError is reported when a chest made from a chest type is used
+// with a name that is neither one of its fields nor one of its methods.
+func unknownFieldError(chestType *object.ChestType, name string) object.Object {
+	candidates := chestType.FieldNames()
+	methods := make([]string, 0, len(chestType.Methods))
+	for m := range chestType.Methods {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	candidates = append(candidates, methods...)
+	if suggestion := closestName(name, candidates); suggestion != "" {
+		return newEvaluationError("unknown field for %s: %s (did you mean %s?)", chestType.Name, name, suggestion)
+	}
+	return newEvaluationError("unknown field for %s: %s", chestType.Name, name)
+}
+
+// bindMethod gives the method its own namespace where `self` is the chest it
+// was accessed through.
+func bindMethod(method *object.Function, chest *object.Chest) *object.Function {
+	ns := object.NewNestedNamespace(method.NS)
+	ns.Set("self", chest)
+	bound := *method
+	bound.NS = ns
+	return &bound
 }
 
 func evalChestFieldAssignmentNode(node *ast.ChestFieldAssignment, ns *object.Namespace) object.Object {
@@ -303,11 +532,25 @@ func evalChestFieldAssignmentNode(node *ast.ChestFieldAssignment, ns *object.Nam
 	if !ok {
 		return newEvaluationError("not a chest: %s", left.Type())
 	}
-	val := Eval(node.Value, ns)
+	val := evalAssignedValue(node.Operator, func() object.Object {
+		return evalChestAccess(chest, node.Field.Value)
+	}, node.Value, ns)
 	if object.IsError(val) {
 		return val
 	}
-	chest.Items[node.Field.Value] = val
+	if chest.ChestType != nil {
+		field, ok := chest.ChestType.Field(node.Field.Value)
+		if !ok {
+			if _, isMethod := chest.ChestType.Methods[node.Field.Value]; isMethod {
+				return newEvaluationError("cannot assign to method %s of %s", node.Field.Value, chest.ChestType.Name)
+			}
+			return unknownFieldError(chest.ChestType, node.Field.Value)
+		}
+		if err := validateField(chest.ChestType, field, val); err != nil {
+			return err
+		}
+	}
+	chest.Set(node.Field.Value, val)
 	return MT
 }
 
@@ -320,28 +563,146 @@ func evalFuncCallNode(node *ast.CallExpression, ns *object.Namespace) object.Obj
 	if len(args) == 1 && object.IsError(args[0]) {
 		return args[0]
 	}
-	return callFunc(f, args)
+	named := make([]namedArg, len(node.NamedArguments))
+	for i, arg := range node.NamedArguments {
+		value := Eval(arg.Value, ns)
+		if object.IsError(value) {
+			return value
+		}
+		named[i] = namedArg{name: arg.Name.Value, value: value}
+	}
+	return callFunc(f, args, named...)
 }
 
-func callFunc(f object.Object, args []object.Object) object.Object {
-	switch f := f.(type) {
+// namedArg is an argument passed by parameter name, e.g. `greet(name: "Jack")`.
+type namedArg struct {
+	name  string
+	value object.Object
+}
+
+func callFunc(f object.Object, args []object.Object, named ...namedArg) object.Object {
+	switch fn := f.(type) {
 	case *object.Function:
-		localNS := newFunctionNamespace(f, args)
-		result := Eval(f.Body, localNS)
-		return extractGivesValue(result)
+		f = &function{Function: fn, named: named}
 	case *object.Builtin:
-		return f.Fn(args...)
-	default:
-		return newEvaluationError("Not a function: %s", f.Type())
+		if len(named) > 0 {
+			return newEvaluationError("%s does not take named arguments", typeName(f))
+		}
 	}
+	if callable, ok := f.(object.Callable); ok {
+		return callable.Call(args...)
+	}
+	if handler, ok := chestMethod(f, "call"); ok {
+		return callFunc(handler, args, named...)
+	}
+	return newEvaluationError("Not a function: %s", f.Type())
+}
+
+// function makes a *object.Function Callable. Calling one needs the
+// evaluator, and Call only takes positional arguments, so the named ones of
+// the call are carried along.
+type function struct {
+	*object.Function
+	named []namedArg
 }
 
-func newFunctionNamespace(f *object.Function, args []object.Object) *object.Namespace {
+func (f *function) Call(args ...object.Object) object.Object {
+	localNS, err := newFunctionNamespace(f.Function, args, f.named)
+	if err != nil {
+		return err
+	}
+	result := Eval(f.Body, localNS)
+	return extractGivesValue(result)
+}
+
+// newFunctionNamespace binds the arguments of a call: positional ones first,
+// extra ones to the rest parameter, then named ones, then defaults for the
+// parameters left. Defaults are evaluated in the new namespace, so they can
+// use the parameters bound before them.
+func newFunctionNamespace(f *object.Function, args []object.Object, named []namedArg) (*object.Namespace, object.Object) {
+	if len(args) > len(f.Params) && f.Rest == nil {
+		return nil, wrongArgumentCount(f, len(args))
+	}
 	localNS := object.NewNestedNamespace(f.NS)
+	bound := make(map[string]bool)
+	for i, param := range f.Params {
+		if i < len(args) {
+			localNS.Set(param.Value, args[i])
+			bound[param.Value] = true
+		}
+	}
+	if f.Rest != nil {
+		extra := []object.Object{}
+		if len(args) > len(f.Params) {
+			extra = slices.Clone(args[len(f.Params):])
+		}
+		localNS.Set(f.Rest.Value, &object.Array{Elements: extra})
+	}
+
+	for _, arg := range named {
+		isParam := slices.ContainsFunc(f.Params, func(param *ast.Identifier) bool {
+			return param.Value == arg.name
+		})
+		if !isParam {
+			return nil, unknownArgumentError(f, arg.name)
+		}
+		if bound[arg.name] {
+			return nil, newEvaluationError("argument given twice%s: %s", callName(f), arg.name)
+		}
+		localNS.Set(arg.name, arg.value)
+		bound[arg.name] = true
+	}
+
+	for _, param := range f.Params {
+		if bound[param.Value] {
+			continue
+		}
+		def, ok := f.Defaults[param.Value]
+		if !ok {
+			if len(named) == 0 {
+				return nil, wrongArgumentCount(f, len(args))
+			}
+			return nil, newEvaluationError("missing argument%s: %s", callName(f), param.Value)
+		}
+		value := Eval(def, localNS)
+		if object.IsError(value) {
+			return nil, value
+		}
+		localNS.Set(param.Value, value)
+	}
+	return localNS, nil
+}
+
+// callName names the function in errors about its arguments, e.g. " to `greet`".
+func callName(f *object.Function) string {
+	if f.Name == "" {
+		return ""
+	}
+	return " to `" + f.Name + "`"
+}
+
+func wrongArgumentCount(f *object.Function, got int) object.Object {
+	required := len(f.Params) - len(f.Defaults)
+	switch {
+	case f.Rest != nil:
+		return newEvaluationError("wrong number of arguments%s. got=%d, expected at least %d", callName(f), got, required)
+	case required == len(f.Params):
+		return newEvaluationError("wrong number of arguments%s. got=%d, expected=%d", callName(f), got, required)
+	default:
+		return newEvaluationError("wrong number of arguments%s. got=%d, expected=%d to %d",
+			callName(f), got, required, len(f.Params))
+	}
+}
+
+func unknownArgumentError(f *object.Function, name string) object.Object {
+	names := make([]string, len(f.Params))
 	for i, param := range f.Params {
-		localNS.Set(param.Value, args[i])
+		names[i] = param.Value
 	}
-	return localNS
+	if suggestion := closestName(name, names); suggestion != "" {
+		return newEvaluationError("unknown argument%s: %s (did you mean %s?)", callName(f), name, suggestion)
+	}
+	return newEvaluationError("unknown argument%s: %s", callName(f), name)
 }
 
 func extractGivesValue(obj object.Object) object.Object {
@@ -365,9 +726,15 @@ func evalExpressions(exps []ast.Expression, ns *object.Namespace) []object.Objec
 }
 
 func evalFuncLiteral(node *ast.FunctionLiteral, ns *object.Namespace) object.Object {
-	params := node.Params
-	body := node.Body
-	return &object.Function{Params: params, NS: ns, Body: body}
+	return &object.Function{
+		Name:     node.Name,
+		Doc:      node.Doc,
+		Params:   node.Params,
+		Defaults: node.Defaults,
+		Rest:     node.Rest,
+		NS:       ns,
+		Body:     node.Body,
+	}
 }
 
 func evalIdentifier(node *ast.Identifier, ns *object.Namespace) object.Object {
@@ -382,14 +749,40 @@ func evalIdentifier(node *ast.Identifier, ns *object.Namespace) object.Object {
 }
 
 func evalYarStatementNode(node *ast.YarStatement, ns *object.Namespace) object.Object {
-	val := Eval(node.Value, ns)
+	val := evalAssignedValue(node.Operator, func() object.Object {
+		return evalIdentifier(node.Name, ns)
+	}, node.Value, ns)
 	if object.IsError(val) {
 		return val
 	}
+	if node.Pattern != nil {
+		if err := destructure(node.Pattern, val, ns); err != nil {
+			return err
+		}
+		return MT
+	}
 	ns.Set(node.Name.Value, val)
 	return &object.MT{}
 }
 
+// evalAssignedValue gives the value an assignment stores. Compound forms
+// like `x +be 1` read the target once through current before evaluating the
+// right side, then combine the two with the operator.
+func evalAssignedValue(operator string, current func() object.Object, value ast.Expression, ns *object.Namespace) object.Object {
+	if operator == "" {
+		return Eval(value, ns)
+	}
+	old := current()
+	if object.IsError(old) {
+		return old
+	}
+	right := Eval(value, ns)
+	if object.IsError(right) {
+		return right
+	}
+	return evalInfixExpression(old, operator, right)
+}
+
 func evalGivesStatementNode(node *ast.GivesStatement, ns *object.Namespace) object.Object {
 	value := Eval(node.Value, ns)
 	if object.IsError(value) {
@@ -432,6 +825,57 @@ func evalForStatementNode(node *ast.ForStatement, ns *object.Namespace) object.O
 	return MT
 }
 
+func evalForEachStatementNode(node *ast.ForEachStatement, ns *object.Namespace) object.Object {
+	collection := Eval(node.Iterable, ns)
+	if object.IsError(collection) {
+		return collection
+	}
+	iter, err := iterate(collection)
+	if err != nil {
+		return err
+	}
+
+	for {
+		el, ok := iter.Next()
+		if !ok {
+			return MT
+		}
+		if node.Body == nil {
+			continue
+		}
+		loopNS := object.NewLoopNamespace(ns, node.Variable.Value)
+		loopNS.Set(node.Variable.Value, el)
+		result := Eval(node.Body, loopNS)
+		if object.IsError(result) || result.Type() == object.GIVES_VALUE_OBJ {
+			return result
+		}
+		if result == BREAK {
+			return MT
+		}
+	}
+}
+
+// iterate gives an iterator over obj. Chests take part by defining each,
+// which gives something iterable such as an array.
+func iterate(obj object.Object) (object.Iterator, object.Object) {
+	if handler, ok := chestMethod(obj, "each"); ok {
+		result := callFunc(handler, []object.Object{})
+		if object.IsError(result) {
+			return nil, result
+		}
+		iterable, ok := result.(object.Iterable)
+		if !ok {
+			return nil, newEvaluationError("each of %s must give something iterable, got %s", typeName(obj), typeName(result))
+		}
+		return iterable.Iter(), nil
+	}
+	iterable, ok := obj.(object.Iterable)
+	if !ok {
+		return nil, newEvaluationError("cannot loop over %s", typeName(obj))
+	}
+	return iterable.Iter(), nil
+}
+
 func evalIfStatementNode(node *ast.IfStatement, ns *object.Namespace) object.Object {
 	for _, conditional := range node.Conditionals {
 		if cond := Eval(conditional.Condition, ns); cond == AY {
@@ -473,25 +917,60 @@ func evalInfixExpressionNode(node *ast.InfixExpression, ns *object.Namespace) ob
 		return right
 	}
 
+	return evalInfixExpression(left, node.Operator, right)
+}
+
+func evalInfixExpression(left object.Object, operator string, right object.Object) object.Object {
+	if result, ok := evalChestInfixOperator(left, operator, right); ok {
+		return result
+	}
 	switch {
 	case left.Type() == object.INT_OBJ && right.Type() == object.INT_OBJ:
-		return evalIntInfixExpression(left, node.Operator, right)
+		return evalIntInfixExpression(left, operator, right)
 	case left.Type() == object.BOOL_OBJ && right.Type() == object.BOOL_OBJ:
-		return evalBoolInfixExpression(left, node.Operator, right)
+		return evalBoolInfixExpression(left, operator, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
-		return evalStringInfixExpression(left, node.Operator, right)
+		return evalStringInfixExpression(left, operator, right)
+	case left.Type() == object.FLAG_OBJ && right.Type() == object.FLAG_OBJ:
+		return evalFlagInfixExpression(left, operator, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.INT_OBJ && operator == "*":
+		return evalStringRepetition(left, right)
+	case left.Type() == object.INT_OBJ && right.Type() == object.STRING_OBJ && operator == "*":
+		return evalStringRepetition(right, left)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.INT_OBJ:
-		return evalStringInfixExpression(left, node.Operator, castIntToString(right))
+		return evalStringInfixExpression(left, operator, castIntToString(right))
 	case left.Type() == object.INT_OBJ && right.Type() == object.STRING_OBJ:
-		return evalStringInfixExpression(castIntToString(left), node.Operator, right)
+		return evalStringInfixExpression(castIntToString(left), operator, right)
+	case (operator == "=" || operator == "<>") && (isStructural(left) || isStructural(right)):
+		return evalStructuralEquality(left, operator, right)
 	case left.Type() != right.Type():
 		return newEvaluationError("type mismatch: %s %s %s",
-			left.Type(), node.Operator, right.Type())
+			left.Type(), operator, right.Type())
 	default:
 		return newEvaluationError("No infix expression for: %s %s %s",
-			left.Type(), node.Operator, right.Type())
+			left.Type(), operator, right.Type())
+	}
+
+}
+
+// isStructural reports whether obj is compared by content rather than
+// through one of the typed infix handlers. Flags only compare to other flags
+// through their handler, anything else is simply not equal to them.
+func isStructural(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Array, *object.HashMap, *object.Chest, *object.MT, *object.Flag:
+		return true
+	default:
+		return false
 	}
+}
 
+func evalStructuralEquality(left object.Object, operator string, right object.Object) object.Object {
+	equal := object.Equal(left, right)
+	if operator == "<>" {
+		return nativeBoolToBoolObj(!equal)
+	}
+	return nativeBoolToBoolObj(equal)
 }
 
 func castIntToString(obj object.Object) object.Object {
@@ -520,6 +999,37 @@ func evalBoolInfixExpression(left object.Object, operator string, right object.O
 	}
 }
 
+// evalFlagInfixExpression compares members by identity, and orders members of
+// the same flag type by their position in the flag statement.
+func evalFlagInfixExpression(left object.Object, operator string, right object.Object) object.Object {
+	leftFlag := left.(*object.Flag)
+	rightFlag := right.(*object.Flag)
+
+	switch operator {
+	case "=":
+		return nativeBoolToBoolObj(leftFlag == rightFlag)
+	case "<>":
+		return nativeBoolToBoolObj(leftFlag != rightFlag)
+	}
+	if leftFlag.FlagType != rightFlag.FlagType {
+		return newEvaluationError("cannot compare members of different flags: %s %s %s",
+			leftFlag.FlagType.Name, operator, rightFlag.FlagType.Name)
+	}
+	switch operator {
+	case "<":
+		return nativeBoolToBoolObj(leftFlag.Ordinal < rightFlag.Ordinal)
+	case ">":
+		return nativeBoolToBoolObj(leftFlag.Ordinal > rightFlag.Ordinal)
+	case "<=":
+		return nativeBoolToBoolObj(leftFlag.Ordinal <= rightFlag.Ordinal)
+	case ">=":
+		return nativeBoolToBoolObj(leftFlag.Ordinal >= rightFlag.Ordinal)
+	default:
+		return newEvaluationError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
 func evalStringInfixExpression(left object.Object, operator string, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
@@ -531,12 +1041,35 @@ func evalStringInfixExpression(left object.Object, operator string, right object
 		return nativeBoolToBoolObj(leftVal == rightVal)
 	case "<>":
 		return nativeBoolToBoolObj(leftVal != rightVal)
+	case "<":
+		return nativeBoolToBoolObj(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBoolObj(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBoolObj(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBoolObj(leftVal >= rightVal)
 	default:
 		return newEvaluationError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 }
 
+// maxStringLength caps the strings repetition and padding build, in bytes.
+const maxStringLength = 1 << 28
+
+func evalStringRepetition(str, count object.Object) object.Object {
+	s := str.(*object.String).Value
+	n := count.(*object.Int).Value
+	if n < 0 {
+		return newEvaluationError("negative repeat count: %d", n)
+	}
+	if len(s) > 0 && n > maxStringLength/int64(len(s)) {
+		return newEvaluationError("string too long to repeat %d times", n)
+	}
+	return nativeStringToStringObj(strings.Repeat(s, int(n)))
+}
+
 func evalIntInfixExpression(left object.Object, operator string, right object.Object) object.Object {
 	leftVal := left.(*object.Int).Value
 	rightVal := right.(*object.Int).Value
@@ -576,6 +1109,10 @@ func evalPrefixExpressionNode(node *ast.PrefixExpression, ns *object.Namespace)
 		return operand
 	}
 
+	if result, ok := evalChestPrefixOperator(node.Operator, operand); ok {
+		return result
+	}
+
 	switch node.Operator {
 	case "!":
 		return evalLogicalNegateExpression(operand)
@@ -281,8 +281,28 @@ func TestErrorHandling(t *testing.T) {
 			"index out of bounds. len=3, index=6",
 		},
 		{
-			"[1, 2, 3][-1]",
-			"index out of bounds. len=3, index=-1",
+			"[1, 2, 3][-4]",
+			"index out of bounds. len=3, index=-4",
+		},
+		{
+			`"ay"[2]`,
+			"index out of bounds. len=2, index=2",
+		},
+		{
+			`"ay"["a":]`,
+			"slice bounds must be INT, got STRING",
+		},
+		{
+			`{"a": 1}[0:1]`,
+			"slice operator not supported: HASHMAP",
+		},
+		{
+			`"ay" * -1`,
+			"negative repeat count: -1",
+		},
+		{
+			`"ab" * 9223372036854775807`,
+			"string too long to repeat 9223372036854775807 times",
 		},
 		{
 			`{"a": "b"}[f(x): x..]`,
@@ -402,6 +422,12 @@ func TestStringCompare(t *testing.T) {
 		{"'hello' = \"hello\"", true},
 		{"'hello' = 'hlo'", false},
 		{"'hello' <> 'hello'", false},
+		{"'apple' < 'banana'", true},
+		{"'apple' > 'banana'", false},
+		{"'ab' < 'abc'", true},
+		{"'abc' <= 'abc'", true},
+		{"'b' >= 'abc'", true},
+		{"'Z' < 'a'", true},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -417,8 +443,10 @@ func TestBuiltins(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("ñ")`, 1},
+		{`len("🦜 ay")`, 4},
 		{`len(1)`, "argument to `len` not supported, got INT"},
-		{`len("one", "two")`, "wrong number of args. got=2, expected=1"},
+		{`len("one", "two")`, "wrong number of arguments. got=2, expected=1"},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -528,23 +556,32 @@ func TestHashLiterals(t *testing.T) {
 	if !ok {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).Hash():   1,
-		(&object.String{Value: "two"}).Hash():   2,
-		(&object.String{Value: "three"}).Hash(): 3,
-		(&object.Int{Value: 3}).Hash():          3,
-		AY.Hash():                               5,
-		NAY.Hash():                              6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Int{Value: 3}, 3},
+		{AY, 5},
+		{NAY, 6},
 	}
-	if len(result.MP) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.MP))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.MP[expectedKey]
+	for _, tt := range expected {
+		pair, ok := result.Get(tt.key)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
 		}
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, pair.Value, tt.value)
+	}
+	for i, pair := range result.Pairs() {
+		if pair.Key.AsString() != expected[i].key.AsString() {
+			t.Errorf("pair %d has wrong key. expected=%s, got=%s",
+				i, expected[i].key.AsString(), pair.Key.AsString())
+		}
 	}
 }
 
@@ -685,7 +722,7 @@ b.
 	if !ok {
 		t.Fatalf("object not Chest. got=%T", evaluated)
 	}
-	expected := `|yes: "one"|`
+	expected := `it|yes: "one"|`
 	actual := chest.AsString()
 	if actual != expected {
 		t.Fatalf("chest AsString wrong. expected=%q, got=%q", expected, actual)
@@ -704,3 +741,1009 @@ inst|foo().
 	evaluated := testEval(input)
 	testIntegerObject(t, evaluated, 5)
 }
+
+func TestStringEscapesAndInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"line\nbreak"`, "line\nbreak"},
+		{`'say \'ay\''`, "say 'ay'"},
+		{`yar name be "matey". f"ahoy {name}!"`, "ahoy matey!"},
+		{`yar x be 2. f"{x} + {x} = {x + x}"`, "2 + 2 = 4"},
+		{`f"{{ {[1, 2][1]} }}"`, "{ 2 }"},
+		{`yar greet be f(n): gives f"hi {n}\t".. greet("jack")`, "hi jack\t"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+}
+
+func TestStringIndexAndSlice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"matey"[0]`, "m"},
+		{`"matey"[-1]`, "y"},
+		{`"ñandú"[4]`, "ú"},
+		{`"matey"[1:3]`, "at"},
+		{`"matey"[:2]`, "ma"},
+		{`"matey"[2:]`, "tey"},
+		{`"matey"[-3:]`, "tey"},
+		{`"matey"[:]`, "matey"},
+		{`"matey"[3:1]`, ""},
+		{`"matey"[1:100]`, "atey"},
+		{`"ñandú"[1:-1]`, "and"},
+		{`"ab" * 3`, "ababab"},
+		{`"" * 9223372036854775807`, ""},
+		{`2 * "yo"`, "yoyo"},
+		{`"ab" * 0`, ""},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("object is not String for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value for %q. expected=%q, got=%q", tt.input, tt.expected, str.Value)
+		}
+	}
+}
+
+func TestArraySlicesAndNegativeIndices(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3][-1]", "3"},
+		{"[1, 2, 3][-3]", "1"},
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4][:-1]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4][-2:]", "[3, 4]"},
+		{"[1, 2, 3][5:]", "[]"},
+		{"yar a be [1, 2, 3]. a[-1] be 9. a.", "[1, 2, 9]"},
+		{"yar a be [1, 2, 3]. yar b be a[:]. b[0] be 9. a.", "[1, 2, 3]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType object.ObjectType
+		expected     string
+	}{
+		{`split("a,b,,c", ",")`, object.ARRAY_OBJ, `["a", "b", "", "c"]`},
+		{`split("  yo  ho ho ")`, object.ARRAY_OBJ, `["yo", "ho", "ho"]`},
+		{`join(["a", "b", 3], "-")`, object.STRING_OBJ, "a-b-3"},
+		{`join(["a", "b"])`, object.STRING_OBJ, "ab"},
+		{`upper("ñandú ay")`, object.STRING_OBJ, "ÑANDÚ AY"},
+		{`lower("MaTeY")`, object.STRING_OBJ, "matey"},
+		{`trim("  \tay\n ")`, object.STRING_OBJ, "ay"},
+		{`trim("--ay--", "-")`, object.STRING_OBJ, "ay"},
+		{`replace("a.b.c", ".", "/")`, object.STRING_OBJ, "a/b/c"},
+		{`replace("a.b.c", ".", "/", 1)`, object.STRING_OBJ, "a/b.c"},
+		{`contains("treasure", "sure")`, object.BOOL_OBJ, "ay"},
+		{`contains("treasure", "gold")`, object.BOOL_OBJ, "nay"},
+		{`startsWith("treasure", "tr")`, object.BOOL_OBJ, "ay"},
+		{`endsWith("treasure", "tr")`, object.BOOL_OBJ, "nay"},
+		{`find("ñandú", "dú")`, object.INT_OBJ, "3"},
+		{`find("ñandú", "x")`, object.INT_OBJ, "-1"},
+		{`repeat("yo", 3)`, object.STRING_OBJ, "yoyoyo"},
+		{`pad("7", 3)`, object.STRING_OBJ, "  7"},
+		{`pad("7", -3, ".")`, object.STRING_OBJ, "7.."},
+		{`pad("ñ", 3, "0")`, object.STRING_OBJ, "00ñ"},
+		{`pad("matey", 2)`, object.STRING_OBJ, "matey"},
+		{`chars("añb")`, object.ARRAY_OBJ, `["a", "ñ", "b"]`},
+		{`chars("")`, object.ARRAY_OBJ, "[]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() != tt.expectedType {
+			t.Errorf("wrong type for %s. expected=%s, got=%s (%s)",
+				tt.input, tt.expectedType, evaluated.Type(), evaluated.AsString())
+			continue
+		}
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestStringBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`split(1)`, "first argument to `split` must be STRING, got INT"},
+		{`split("a", ",", "b")`, "wrong number of arguments. got=3, expected=1 to 2"},
+		{`join("ab")`, "first argument to `join` must be ARRAY, got STRING"},
+		{`upper()`, "wrong number of arguments. got=0, expected=1"},
+		{`replace("a", "b", 1)`, "third argument to `replace` must be STRING, got INT"},
+		{`replace("a", "b", "c", "d")`, "fourth argument to `replace` must be INT, got STRING"},
+		{`contains("a", ay)`, "second argument to `contains` must be STRING, got BOOL"},
+		{`repeat("a", -1)`, "negative repeat count: -1"},
+		{`repeat("ab", 4611686018427387904)`, "string too long to repeat 4611686018427387904 times"},
+		{`pad("a", -9223372036854775807)`, "width for `pad` too large: -9223372036854775807"},
+		{`pad("a", 3, "ab")`, "fill for `pad` must be a single character, got \"ab\""},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error for %s. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], f(x): x * 2..)`, "[2, 4, 6]"},
+		{`map(["a", "b"], upper)`, `["A", "B"]`},
+		{`filter([1, 2, 3, 4], f(x): x mod 2 = 0..)`, "[2, 4]"},
+		{`reduce([1, 2, 3, 4], f(acc, x): acc + x..)`, "10"},
+		{`reduce([1, 2, 3], f(acc, x): acc + x.., 10)`, "16"},
+		{`reduce([], f(acc, x): acc + x.., 0)`, "0"},
+		{`any([1, 2, 3], f(x): x > 2..)`, "ay"},
+		{`any([1, 2, 3], f(x): x > 3..)`, "nay"},
+		{`all([1, 2, 3], f(x): x > 0..)`, "ay"},
+		{`all([ay, nay])`, "nay"},
+		{`any([])`, "nay"},
+		{`all([])`, "ay"},
+		{`find([1, 2, 3, 4], f(x): x > 2..)`, "3"},
+		{`find([1, 2], f(x): x > 2..)`, "MT"},
+		{`zip([1, 2, 3], ["a", "b"])`, `[[1, "a"], [2, "b"]]`},
+		{`enumerate(["a", "b"])`, `[[0, "a"], [1, "b"]]`},
+		{`flatten([1, [2, [3, [4]]], 5])`, "[1, 2, [3, [4]], 5]"},
+		{`flatten([1, [2, [3, [4]]], 5], 10)`, "[1, 2, 3, 4, 5]"},
+		{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+		{`reverse("ñandú")`, "údnañ"},
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, `["a", "b", "c"]`},
+		{`sort([3, 1, 2], f(a, b): a > b..)`, "[3, 2, 1]"},
+		{`sort([[2, "a"], [1, "b"], [2, "c"], [1, "d"]], f(a, b): a[0] < b[0]..)`, `[[1, "b"], [1, "d"], [2, "a"], [2, "c"]]`},
+		{`yar a be [3, 1, 2]. sort(a). a`, "[3, 1, 2]"},
+		{`yar total be 0. map([1, 2], f(x): total + x..)`, "[1, 2]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestHigherOrderBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map(1, f(x): x..)`, "first argument to `map` must be ARRAY, got INT"},
+		{`map([1], 2)`, "second argument to `map` must be FUNCTION, got INT"},
+		{`map([1], f(x): x + ay..)`, "type mismatch: INT + BOOL"},
+		{`map([1], f(x, y): x..)`, "wrong number of arguments. got=1, expected=2"},
+		{`filter([1], f(x): x..)`, "function passed to `filter` must give BOOL, got INT"},
+		{`reduce([], f(acc, x): acc..)`, "reduce of empty ARRAY with no initial value"},
+		{`all([1])`, "elements passed to `all` must be BOOL, got INT"},
+		{`zip([1], "a")`, "arguments to `zip` must be ARRAY, got STRING"},
+		{`sort([1, "a"])`, "cannot compare INT and STRING in `sort`"},
+		{`sort([ay, nay])`, "unknown operator: BOOL < BOOL"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error for %s. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestHashMapBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`yar m be {"b": 1}. m["a"] be 2. m[3] be 3. m[ay] be 4. keys(m)`, `["b", "a", 3, ay]`},
+		{`yar m be {"b": 1}. m["a"] be 2. values(m)`, "[1, 2]"},
+		{`yar m be {"b": 1}. m["a"] be 2. items(m)`, `[["b", 1], ["a", 2]]`},
+		{`len({"a": 1, "b": 2})`, "2"},
+		{`len({})`, "0"},
+		{`has({"a": 1}, "a")`, "ay"},
+		{`has({"a": 1}, "b")`, "nay"},
+		{`yar m be {"a": pop([])}. [m["a"], has(m, "a"), has(m, "b")]`, "[MT, ay, nay]"},
+		{`yar m be {"a": 1, "b": 2}. delete(m, "a")`, "1"},
+		{`yar m be {"a": 1, "b": 2}. delete(m, "a"). keys(m)`, `["b"]`},
+		{`delete({"a": 1}, "z")`, "MT"},
+		{`getOr({"a": 1}, "a", 0)`, "1"},
+		{`getOr({"a": 1}, "b", 0)`, "0"},
+		{`yar m be {"b": 1}. m["a"] be 2. items(merge(m, {"a": 3}, {"c": 4}))`, `[["b", 1], ["a", 3], ["c", 4]]`},
+		{`yar m be {"a": 1}. merge(m, {"b": 2}). len(m)`, "1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestHashMapBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys([1])`, "first argument to `keys` must be HASHMAP, got ARRAY"},
+		{`has({}, [f(): 1..])`, "Object not hashable. Type=ARRAY"},
+		{`getOr({}, "a")`, "wrong number of arguments. got=2, expected=3"},
+		{`merge({}, [])`, "arguments to `merge` must be HASHMAP, got ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error for %s. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestLiteralEvaluationOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, `{"z": 1, "a": 2, "m": 3}`},
+		{`|z: 1, a: 2, m: 3|`, "|z: 1, a: 2, m: 3|"},
+		{`chest Point|y, x|. Point|x: 1, y: 2|`, "Point|y: 2, x: 1|"},
+		{`yar log be []. yar note be f(x): push(log, x). x...
+		{note("k1"): note(1), note("k2"): note(2), note("k3"): note(3)}. log`,
+			`["k1", 1, "k2", 2, "k3", 3]`},
+		{`yar log be []. yar note be f(x): push(log, x). x...
+		|c: note(1), b: note(2), a: note(3)|. log`,
+			`[1, 2, 3]`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestDuplicateComputedHashMapKey(t *testing.T) {
+	evaluated := testEval(`yar k be "a". {k: 1, "a": 2}`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	expected := "duplicate key in hashmap literal: a"
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+	}
+}
+
+func TestCompositeHashMapKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`yar m be {[1, 2]: "a", [2, 1]: "b"}. m[[1, 2]]`, "a"},
+		{`yar m be {[1, [2, "x"]]: "deep"}. m[[1, [2, "x"]]]`, "deep"},
+		{`yar k be [1, 2]. yar m be {k: "a"}. push(k, 3). [m[[1, 2]], m[k]]`, `["a", MT]`},
+		{`yar m be {}. m[|x: 1, y: 2|] be "p". m[|y: 2, x: 1|]`, "p"},
+		{`chest Point|x, y|. yar m be {}. m[Point|1, 2|] be "p". has(m, Point|1, 2|)`, "ay"},
+		{`yar m be {[]: 1, "": 2, 0: 3}. len(m)`, "3"},
+		{`{[1, {}]: 1}`, "ERROR: Object not hashable. Type=ARRAY"},
+		{`yar g be f(): 1... yar m be {}. m[|fn: g|] be 1.`, "ERROR: Object not hashable. Type=CHEST"},
+		{`yar a be [1]. push(a, a). has({}, a)`, "ERROR: Object not hashable. Type=ARRAY"},
+		{`yar a be [1]. push(a, a). yar m be {}. m[a] be 1.`, "ERROR: Object not hashable. Type=ARRAY"},
+		{`yar c be |x: 1|. c|me be [c]. {c: 1}`, "ERROR: Object not hashable. Type=CHEST"},
+		{`yar a be [1]. yar m be {}. m[[a, a]] be 1. len(m)`, "1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`[1, "a", [ay]] = [1, "a", [ay]]`, true},
+		{`[1, 2] = [2, 1]`, false},
+		{`[1, 2] <> [1, 2, 3]`, true},
+		{`[] = []`, true},
+		{`{"a": 1, "b": [2]} = {"b": [2], "a": 1}`, true},
+		{`{"a": 1} = {"a": 2}`, false},
+		{`{"a": 1} = {"b": 1}`, false},
+		{`|x: 1, y: [2]| = |y: [2], x: 1|`, true},
+		{`|x: 1| = |x: 1, y: 2|`, false},
+		{`chest P|x|. P|1| = P|1|`, true},
+		{`chest P|x|. P|1| = |x: 1|`, false},
+		{`pop([]) = peek([])`, true},
+		{`yar m be {}. m["missing"] = pop([])`, true},
+		{`1 = pop([])`, false},
+		{`pop([]) <> [pop([])]`, true},
+		{`[1] = 1`, false},
+		{`yar a be [1]. push(a, a). yar b be [1]. push(b, b). a = b`, true},
+		{`yar a be [1]. push(a, a). yar b be [2]. push(b, b). a = b`, false},
+		{`yar m be {}. m["self"] be m. yar n be {}. n["self"] be n. m = n`, true},
+		{`yar g be f(): 1... [g] = [g]`, true},
+		{`[f(): 1..] = [f(): 1..]`, false},
+		{`same([1], [1])`, false},
+		{`yar a be [1]. yar b be a. same(a, b)`, true},
+		{`same(pop([]), pop([]))`, true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %s", tt.input)
+		}
+	}
+}
+
+func TestPrintingValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"plain"`, `plain`},
+		{`repr("plain")`, `"plain"`},
+		{`repr("say \"hi\"\n\tbye\\")`, `"say \"hi\"\n\tbye\\"`},
+		{`repr("\u{7}")`, `"\u{7}"`},
+		{`repr(1)`, `1`},
+		{`repr([1, "a", ay, pop([])])`, `[1, "a", ay, MT]`},
+		{`["a", {"b": |c: "d"|}]`, `["a", {"b": |c: "d"|}]`},
+		{`yar a be [1]. push(a, a). a`, `[1, [...]]`},
+		{`yar a be [1]. push(a, [a]). repr(a)`, `[1, [[...]]]`},
+		{`yar m be {"k": 1}. m["self"] be m. m`, `{"k": 1, "self": {...}}`},
+		{`yar c be |x: 1, me: 0|. c|me be c. c`, `|x: 1, me: |...||`},
+		{`yar a be [1]. [a, a]`, `[[1], [1]]`},
+		{`repr()`, `ERROR: wrong number of arguments. got=0, expected=1`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestTypeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`type(1)`, "INT"},
+		{`type("a")`, "STRING"},
+		{`type([1])`, "ARRAY"},
+		{`type({})`, "HASHMAP"},
+		{`type(pop([]))`, "MT"},
+		{`type(f(): 1..)`, "FUNCTION"},
+		{`type(len)`, "BUILTIN"},
+		{`type(|x: 1|)`, "CHEST"},
+		{`chest Point|x, y|. type(Point|1, 2|)`, "Point"},
+		{`chest Point|x, y|. type(Point)`, "CHEST_TYPE"},
+		{`chest Point|x, y|. isType(Point|1, 2|, "Point")`, "ay"},
+		{`chest Point|x, y|. isType(Point|1, 2|, "CHEST")`, "ay"},
+		{`isType(1, "STRING")`, "nay"},
+		{`isType(1, 1)`, "ERROR: second argument to `isType` must be STRING, got INT"},
+		{`int("42")`, "42"},
+		{`int("-7")`, "-7"},
+		{`int(ay)`, "1"},
+		{`int(5)`, "5"},
+		{`int("4x")`, `ERROR: cannot convert "4x" to INT`},
+		{`int("")`, `ERROR: cannot convert "" to INT`},
+		{`int([1])`, "ERROR: cannot convert ARRAY to INT"},
+		{`str(42) + "!"`, "42!"},
+		{`str([1, "a"])`, `[1, "a"]`},
+		{`str(nay)`, "nay"},
+		{`bool(0)`, "nay"},
+		{`bool(3)`, "ay"},
+		{`bool("ay")`, "ay"},
+		{`bool(pop([]))`, "nay"},
+		{`bool("yes")`, `ERROR: cannot convert "yes" to BOOL`},
+		{`bool({})`, "ERROR: cannot convert HASHMAP to BOOL"},
+		{`type()`, "ERROR: wrong number of arguments. got=0, expected=1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestChestMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`chest Point|x, y|:
+			norm be f(): gives self|x * self|x + self|y * self|y..
+		.
+		yar p be Point|3, 4|. p|norm()`, "25"},
+		{`chest Counter|n|:
+			add be f(by):
+				self|n be self|n + by.
+				gives self.
+			.
+		.
+		yar c be Counter|0|.
+		c|add(2).
+		c|add(3)|n`, "5"},
+		{`chest Counter|n|:
+			add be f(by): self|n be self|n + by..
+		.
+		yar a be Counter|0|. yar b be Counter|10|.
+		yar addToA be a|add.
+		addToA(1). b|add(1). [a|n, b|n]`, "[1, 11]"},
+		{`yar base be 100.
+		chest Box|v|:
+			total be f(): gives self|v + base..
+		.
+		yar b be Box|1|. b|total()`, "101"},
+		{`chest P|x|: x be f(): gives 1..
+		.`, "ERROR: method x of P clashes with a field"},
+		{`chest P|x|. P`, "chest P|x|"},
+		{`chest P|x, y|. P|1|`, "ERROR: wrong number of fields for P. expected=2, got=1"},
+		{`chest P|x|. P|y: 1|`, "ERROR: unknown field for P: y"},
+		{`chest P|x|: get be f(): gives self|x.. .
+		yar p be P|1|. p|get()`, "1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestChestFieldDefaultsAndValidation(t *testing.T) {
+	ship := "chest Ship|name, crew be 10, parrot?, gold be 0 if f(g): g >= 0..|.\n"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{ship + `Ship|"Pearl"|`, `Ship|name: "Pearl", crew: 10, parrot: MT, gold: 0|`},
+		{ship + `Ship|"Pearl", 3, "Polly", 7|`, `Ship|name: "Pearl", crew: 3, parrot: "Polly", gold: 7|`},
+		{ship + `Ship|gold: 5, name: "Pearl"|`, `Ship|name: "Pearl", crew: 10, parrot: MT, gold: 5|`},
+		{ship + `Ship||`, "ERROR: wrong number of fields for Ship. expected=1 to 4, got=0"},
+		{ship + `Ship|"a", 1, 2, 3, 4|`, "ERROR: wrong number of fields for Ship. expected=1 to 4, got=5"},
+		{ship + `Ship|crew: 5|`, "ERROR: missing field for Ship: name"},
+		{ship + `Ship|"Pearl", 3, "Polly", -1|`, "ERROR: invalid value for field gold of Ship: -1"},
+		{ship + `yar s be Ship|"Pearl"|. s|gold be -5.`, "ERROR: invalid value for field gold of Ship: -5"},
+		{ship + `yar s be Ship|"Pearl"|. s|gold be 5. s|gold`, "5"},
+		{`chest Bag|items be []|. yar a be Bag||. yar b be Bag||. push(a|items, 1). [a, b]`,
+			"[Bag|items: [1]|, Bag|items: []|]"},
+		{`yar start be 3. chest C|n be start|. start be 4. C||`, "C|n: 4|"},
+		{`chest N|v if isEven|. yar isEven be f(x): x mod 2 = 0... N|2|`, "ERROR: Identifier not found: isEven"},
+		{`yar isEven be f(x): x mod 2 = 0... chest N|v if isEven|. N|3|`, "ERROR: invalid value for field v of N: 3"},
+		{`chest N|v if f(x): 1..|. N|3|`, "ERROR: validator for field v of N must give BOOL, got INT"},
+		{`chest N|v if 1|. N|3|`, "ERROR: validator for field v of N must be a function, got INT"},
+		{`chest N|v be "x" if f(x): len(x) > 1..|. N||`, `ERROR: invalid value for field v of N: "x"`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestChestInheritance(t *testing.T) {
+	pirates := `
+chest Pirate|name, gold be 0 if f(g): g >= 0..|:
+  greet be f(): gives "Arr, I be " + self|name..
+  rank be f(): gives "deckhand"..
+.
+chest Captain(Pirate)|ship, gold be 100|:
+  rank be f(): gives "captain of the " + self|ship..
+.
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{pirates + `Captain|"Jack", 5, "Pearl"|`, `Captain|name: "Jack", gold: 5, ship: "Pearl"|`},
+		{pirates + `Captain|name: "Jack", ship: "Pearl"|`, `Captain|name: "Jack", gold: 100, ship: "Pearl"|`},
+		{pirates + `yar c be Captain|name: "Jack", ship: "Pearl"|. c|greet()`, "Arr, I be Jack"},
+		{pirates + `yar c be Captain|name: "Jack", ship: "Pearl"|. c|rank()`, "captain of the Pearl"},
+		{pirates + `yar p be Pirate|"Anne"|. p|rank()`, "deckhand"},
+		{pirates + `Pirate|name: "Anne", gold: -1|`, "ERROR: invalid value for field gold of Pirate: -1"},
+		{pirates + `Captain|name: "Jack", ship: "Pearl", gold: -1|`, `Captain|name: "Jack", gold: -1, ship: "Pearl"|`},
+		{pirates + `yar c be Captain|name: "Jack", ship: "Pearl"|. [isA(c, Captain), isA(c, Pirate)]`, "[ay, ay]"},
+		{pirates + `yar p be Pirate|"Anne"|. [isA(p, Pirate), isA(p, Captain)]`, "[ay, nay]"},
+		{pirates + `[isA(|name: "x"|, Pirate), isA(1, Pirate)]`, "[nay, nay]"},
+		{pirates + `isA(Pirate|"Anne"|, "Pirate")`, "ERROR: second argument to `isA` must be CHEST_TYPE, got STRING"},
+		{pirates + `type(Captain|"Jack", 5, "Pearl"|)`, "Captain"},
+		{pirates + `Pirate|"Anne"| = Captain|"Anne", 0, "x"|`, "nay"},
+		{`chest A|x be 1 if f(v): v > 0..|. chest B(A)|x if f(v): v < 5..|. [B|-1|, B|7|]`,
+			"ERROR: invalid value for field x of B: 7"},
+		{`chest A|x be 1 if f(v): v > 0..|. chest B(A)|x if f(v): v < 5..|. B||`,
+			"ERROR: wrong number of fields for B. expected=1, got=0"},
+		{`chest A|x?|. chest B(A)|x|. B||`, "ERROR: wrong number of fields for B. expected=1, got=0"},
+		{`chest A|x, y be 2|. chest B(A)|y?, z be 3|. B|1|`, "B|x: 1, y: MT, z: 3|"},
+		{`yar P be 1. chest C(P)|x|.`, "ERROR: C cannot extend INT: not a chest type"},
+		{`chest C(Nope)|x|.`, "ERROR: Identifier not found: Nope"},
+		{`chest A|x|: go be f(): 1.. . chest B(A)|go|.`, "ERROR: method go of B clashes with a field"},
+		{`yar base be 1. chest A|x be base|. yar D be f(): yar base be 2. chest B(A)|y be base|. gives B||... D()`,
+			"B|x: 1, y: 2|"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestStrictChestFields(t *testing.T) {
+	ship := "chest Ship|name, crew|: sail be f(): gives self|name... .\nyar s be Ship|\"Pearl\", 3|.\n"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{ship + `s|nmae`, "ERROR: unknown field for Ship: nmae (did you mean name?)"},
+		{ship + `s|sial()`, "ERROR: unknown field for Ship: sial (did you mean sail?)"},
+		{ship + `s|cannons`, "ERROR: unknown field for Ship: cannons"},
+		{ship + `s|crw be 4.`, "ERROR: unknown field for Ship: crw (did you mean crew?)"},
+		{ship + `s|sail be 4.`, "ERROR: cannot assign to method sail of Ship"},
+		{ship + `s|crew be 4. s|crew`, "4"},
+		{ship + `Ship|name: "Pearl", crue: 3|`, "ERROR: unknown field for Ship: crue (did you mean crew?)"},
+		{`yar c be |x: 1|. c|y be 2. [c|y, c|z]`, "[2, MT]"},
+		{`chest P|x|. chest Q|x|. [P|1| = P|1|, P|1| = Q|1|, P|1| = |x: 1|]`, "[ay, nay, nay]"},
+		{`chest P|x|. chest Q(P)||. [Q|1| = P|1|, Q|1| <> P|1|]`, "[nay, ay]"},
+		{`chest P|x|. yar m be {P|1|: "typed"}. [has(m, P|1|), has(m, |x: 1|)]`, "[ay, nay]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestChestOperatorOverloading(t *testing.T) {
+	vec := `
+chest Vec|x, y|:
+  plus be f(o):
+    yar x be self|x + o|x. yar y be self|y + o|y.
+    gives Vec|x: x, y: y|.
+  .
+  minus be f(o):
+    yar x be self|x - o|x. yar y be self|y - o|y.
+    gives Vec|x: x, y: y|.
+  .
+  times be f(k):
+    yar x be self|x * k. yar y be self|y * k.
+    gives Vec|x: x, y: y|.
+  .
+  negate be f(): gives self * -1..
+  equals be f(o):
+    if !isA(o, Vec):
+      gives nay.
+    .
+    gives self|x = o|x and self|y = o|y.
+  .
+  less be f(o): gives self|x * self|x + self|y * self|y < o|x * o|x + o|y * o|y..
+  ahoy be f(): gives f"<{self|x}, {self|y}>"..
+.
+yar a be Vec|1, 2|. yar b be Vec|3, 4|.
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{vec + `a + b`, "<4, 6>"},
+		{vec + `b - a`, "<2, 2>"},
+		{vec + `a * 3`, "<3, 6>"},
+		{vec + `-a`, "<-1, -2>"},
+		{vec + `a + b = Vec|4, 6|`, "ay"},
+		{vec + `a <> b`, "ay"},
+		{vec + `a = 1`, "nay"},
+		{vec + `1 = a`, "nay"},
+		{vec + `[a < b, b < a]`, "[ay, nay]"},
+		{vec + `sort([b, a, Vec|0, 1|])`, "[<0, 1>, <1, 2>, <3, 4>]"},
+		{vec + `str(a) + "!"`, "<1, 2>!"},
+		{vec + `f"a is {a}"`, "a is <1, 2>"},
+		{vec + `{"a": a}`, `{"a": <1, 2>}`},
+		{vec + `repr([a])`, "[Vec|x: 1, y: 2|]"},
+		{vec + `a / 2`, "ERROR: type mismatch: CHEST / INT"},
+		{vec + `!a`, "ERROR: Unsupported operation !CHEST"},
+		{`chest P|v|: less be f(o): gives 1.. . P|1| < P|2|`, "ERROR: less of P must give BOOL, got INT"},
+		{`chest P|v|: ahoy be f(): gives 1.. . str(P|1|)`, "ERROR: ahoy of P must give STRING, got INT"},
+		{`chest P|v|: ahoy be f(): gives 1.. . [P|1|]`, "ERROR: ahoy of P must give STRING, got INT"},
+		{`chest P|v|: ahoy be f(): gives 1.. . repr([P|1|])`, "[P|v: 1|]"},
+		{`chest P|v|: ahoy be f(): gives "P" + str(self).. . str(P|1|)`, "PP|v: 1|"},
+		{`chest P|v|: ahoy be f(): gives "P" + str(self|v).. . yar p be P|[]|. push(p|v, p). str(p)`, "P[P|v: [...]|]"},
+		{`chest P|v|: ahoy be f(): gives "P".. . repr(P|1|)`, "P|v: 1|"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if Display(evaluated) != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, Display(evaluated))
+		}
+	}
+}
+
+func TestForEachLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`yar total be 0. 4 x in [1, 2, 3]: total be total + x.. total`, "6"},
+		{`yar out be []. 4 c in "añb": push(out, upper(c)).. out`, `["A", "Ñ", "B"]`},
+		{`yar out be []. 4 k in {"b": 1, "a": 2}: push(out, k).. out`, `["b", "a"]`},
+		{`yar out be []. 4 x in [1, 2, 3, 4]: if x = 3: break.. push(out, x).. out`, "[1, 2]"},
+		{`yar first be f(xs): 4 x in xs: gives x.. gives MT.. first([7, 8])`, "7"},
+		{`yar a be [1]. 4 x in a: if x < 3: push(a, x + 1)... a`, "[1, 2, 3]"},
+		{`yar in be [1, 2]. yar total be 0. 4 x in in: total be total + x.. total`, "3"},
+		{`4 x in 5: x..`, "ERROR: cannot loop over INT"},
+		{`4 x in []: x.. x`, "ERROR: Identifier not found: x"},
+		{`4 x in [1, 2]: x.. x`, "ERROR: Identifier not found: x"},
+		{`yar x be 0. 4 x in [1, 2]: x.. x`, "0"},
+		{`yar last be 0. 4 x in [1, 2]: last be x.. last`, "2"},
+		{`yar fs be []. 4 x in [1, 2]: push(fs, f(): x..).. [fs[0](), fs[1]()]`, "[1, 2]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestChestProtocols(t *testing.T) {
+	stack := `
+chest Stack|items be []|:
+  push be f(x): push(self|items, x)..
+  len be f(): gives len(self|items)..
+  at be f(i): gives self|items[len(self|items) - 1 - i]..
+  setAt be f(i, v): self|items[len(self|items) - 1 - i] be v..
+  each be f(): gives reverse(self|items)..
+  call be f(x): gives self|items[0] + x..
+.
+yar s be Stack||.
+s|push(1). s|push(2). s|push(3).
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{stack + `len(s)`, "3"},
+		{stack + `[s[0], s[2]]`, "[3, 1]"},
+		{stack + `s[7]`, "ERROR: index out of bounds. len=3, index=-5"},
+		{stack + `s[0] be 30. s|items`, "[1, 2, 30]"},
+		{stack + `yar out be []. 4 x in s: push(out, x).. out`, "[3, 2, 1]"},
+		{stack + `s(10)`, "11"},
+		{`chest P|v|. yar p be P|1|. p[0]`, "ERROR: index operator not supported: P"},
+		{`chest P|v|. len(P|1|)`, "ERROR: argument to `len` not supported, got CHEST"},
+		{`chest P|v|. 4 x in P|1|: x..`, "ERROR: cannot loop over P"},
+		{`chest P|v|. yar p be P|1|. p(2)`, "ERROR: Not a function: CHEST"},
+		{`chest P|v|: len be f(): gives "3".. . len(P|1|)`, "ERROR: len of P must give INT, got STRING"},
+		{`chest P|v|: each be f(): gives 3.. . 4 x in P|1|: x..`, "ERROR: each of P must give something iterable, got INT"},
+		{`len({"a": 1})`, "1"},
+		{`len(len)`, "ERROR: argument to `len` not supported, got BUILTIN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestFlags(t *testing.T) {
+	colors := "flag Color|Red, Green, Blue|. flag Size|Small, Big|. "
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{colors + "Color|Green", "Color|Green"},
+		{colors + "Color", "flag Color|Red, Green, Blue|"},
+		{colors + "[Color|Red = Color|Red, Color|Red = Color|Blue, Color|Red <> Color|Blue]", "[ay, nay, ay]"},
+		{colors + "[Color|Red < Color|Green, Color|Blue <= Color|Green, Color|Blue >= Color|Blue]", "[ay, nay, ay]"},
+		{colors + "Color|Red = Size|Small", "nay"},
+		{colors + "Color|Red < Size|Big", "ERROR: cannot compare members of different flags: Color < Size"},
+		{colors + "Color|Red + Color|Blue", "ERROR: unknown operator: FLAG + FLAG"},
+		{colors + "Color|Red = 0", "nay"},
+		{colors + "1 <> Color|Red", "ay"},
+		{colors + "[Color|Red, 1] = [Color|Red, 1]", "ay"},
+		{colors + "Color|Red < 0", "ERROR: type mismatch: FLAG < INT"},
+		{colors + "yar c be Color|Green. [type(c), isType(c, \"FLAG\"), isA(c, Color), isA(c, Size), int(c)]",
+			`["Color", ay, ay, nay, 1]`},
+		{colors + "yar names be []. 4 c in Color: push(names, c).. names", "[Color|Red, Color|Green, Color|Blue]"},
+		{colors + "len(Color)", "3"},
+		{colors + "yar h be {(Color|Red): \"stop\"}. h[Color|Green] be \"go\". [h[Color|Red], h[Color|Green], h[Size|Small]]",
+			`["stop", "go", MT]`},
+		{colors + "yar h be {Color|Red: \"stop\", Color|Green: \"go\"}. [h[Color|Red], h[Color|Green]]",
+			`["stop", "go"]`},
+		{"chest P|x|. yar h be {(P|x: 1|): \"one\"}. h[P|1|]", "one"},
+		{colors + "yar c be Color|Blue. f\"{c}!\"", "Color|Blue!"},
+		{colors + "Color|Gren", "ERROR: unknown member for Color: Gren (did you mean Green?)"},
+		{colors + "Color|Purple", "ERROR: unknown member for Color: Purple"},
+		{"yar mood be f(): flag Mood|Happy|. gives Mood|Happy... mood() = mood()", "nay"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestMatchStatement(t *testing.T) {
+	describe := `
+chest Point|x, y|.
+chest Spot(Point)|z be 0|.
+yar describe be f(v):
+  match v:
+  when 0: gives "zero".
+  when -1: gives "minus one".
+  when "hi": gives "greeting".
+  when [first, ..rest] if first > 100: gives f"big {first} then {rest}".
+  when [a, b]: gives f"pair {a} {b}".
+  when [.._, last]: gives f"ends with {last}".
+  when {"name": name, ..others}: gives f"named {name}, also {others}".
+  when Point|x: 0, y|: gives f"on the y axis at {y}".
+  when Point|x, y| if x = y: gives f"diagonal {x}".
+  when Point|x, y|: gives f"point {x} {y}".
+  ls: gives "something else".
+  .
+.
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{describe + "describe(0)", "zero"},
+		{describe + "describe(-1)", "minus one"},
+		{describe + `describe("hi")`, "greeting"},
+		{describe + `describe("0")`, "something else"},
+		{describe + "describe([200, 1, 2])", "big 200 then [1, 2]"},
+		{describe + "describe([1, 2])", "pair 1 2"},
+		{describe + "describe([1, 2, 3])", "ends with 3"},
+		{describe + "describe([])", "something else"},
+		{describe + `describe({"name": "Jack", "ship": "Pearl"})`, `named Jack, also {"ship": "Pearl"}`},
+		{describe + `describe({"ship": "Pearl"})`, "something else"},
+		{`match {"a": 1, "b": 2}: when {"a": a}: "exact" when {"a": a, .._}: "more"..`, "more"},
+		{describe + "describe(Point|0, 5|)", "on the y axis at 5"},
+		{describe + "describe(Point|3, 3|)", "diagonal 3"},
+		{describe + "describe(Spot|1, 2, 9|)", "point 1 2"},
+		{describe + "describe(|x: 1, y: 2|)", "something else"},
+		{"yar x be 1. match [5]: when [x]: x... x", "1"},
+		{"yar count be 0. match 1: when 1: count be count + 1.. count", "1"},
+		{"yar seen be 0. match [4]: when [x]: seen be x. x be 9.. [seen, x]", "ERROR: Identifier not found: x"},
+		{"yar total be 0. 4 v in [[1], 2, [3]]: match v: when [n]: total +be n... total", "4"},
+		{"match [1, [2, 3]]: when [a, [b, ..c]]: [a, b, c]..", "[1, 2, [3]]"},
+		{"yar n be 2. match 2: when (n): \"n\" when _: \"other\"..", "n"},
+		{"flag Color|Red, Green|. match (Color|Green): when (Color|Red): 1 when (Color|Green): 2..", "2"},
+		{"match 7: when 1: 1..", "MT"},
+		{"chest P|x|. yar p be P|2|. match p|x: when 2: \"two\"..", "two"},
+		{"match 3: when x if x > 5: \"big\" ls: \"small\"..", "small"},
+		{"match 3: when x if nope: 1..", "ERROR: Identifier not found: nope"},
+		{"chest P|x|. match P|1|: when P|x, y|: 1 when _: 2..", "2"},
+		{"chest P|x|. match P|1|: when |y|: 1 when |x|: x..", "1"},
+		{"chest P|x|. yar P|x, y| be P|1|.", "ERROR: unknown field for P: y"},
+		{"yar P be 1. match 1: when P|x|: 1..", "ERROR: cannot match against INT: not a chest type"},
+		{"chest Money|cents|: equals be f(o): gives self|cents = o... match Money|5|: when 5: \"five\"..", "five"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"yar [a, b, c] be [1, 2, 3]. [c, b, a]", "[3, 2, 1]"},
+		{"yar [first, ..rest] be [1, 2, 3]. [first, rest]", "[1, [2, 3]]"},
+		{"yar [..init, last] be [1, 2, 3]. [init, last]", "[[1, 2], 3]"},
+		{"yar [a, ..mid, b] be [1, 2]. [a, mid, b]", "[1, [], 2]"},
+		{"yar [[x, y], _] be [[1, 2], 3]. [x, y]", "[1, 2]"},
+		{"yar xs be [1, 2, 3]. yar [..all] be xs. push(all, 4). xs", "[1, 2, 3]"},
+		{`yar |foo, bar| be |foo: "f", bar: "b"|. foo + bar`, "fb"},
+		{"chest Point|x, y|. yar Point|x, y: py| be Point|5, 6|. [x, py]", "[5, 6]"},
+		{`yar {"name": n, ..others} be {"name": "Jack", "ship": "Pearl"}. [n, others]`, `["Jack", {"ship": "Pearl"}]`},
+		{`yar key be "k". yar {(key): v} be {"k": 1}. v`, "1"},
+		{"yar [a, |b|] be [1, |b: 2|]. a + b", "3"},
+		{"yar [p, q] be [1].", "ERROR: wrong number of values to destructure. expected=2, got=1"},
+		{"yar [p, q] be [1, 2, 3].", "ERROR: wrong number of values to destructure. expected=2, got=3"},
+		{"yar [p, ..q, r] be [1].", "ERROR: not enough values to destructure. expected at least 2, got=1"},
+		{`yar {"a": a} be {"b": 1}.`, `ERROR: key not found: "a"`},
+		{`yar {"a": a} be {"a": 1, "b": 2}.`, `ERROR: unexpected key to destructure: "b"`},
+		{`yar {"a": a, .._} be {"a": 1, "b": 2}. a`, "1"},
+		{`yar [a] be "x".`, "ERROR: cannot destructure STRING into an array"},
+		{"yar {1: a} be [1].", "ERROR: cannot destructure ARRAY into a hash map"},
+		{"yar |a| be |b: 1|.", "ERROR: missing field to destructure: a"},
+		{"chest P|x|. chest Q|x|. yar P|x| be Q|1|.", "ERROR: cannot destructure Q into P"},
+		{"chest P|x|. yar |y| be P|1|.", "ERROR: unknown field for P: y"},
+		{"yar [1, a] be [2, 3].", "ERROR: 2 does not match 1"},
+		{"yar [a, b] be nope.", "ERROR: Identifier not found: nope"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	counted := "yar calls be []. yar idx be f(): push(calls, 1). gives 0... "
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"yar i be 1. i +be 2. i *be 5. i -be 1. i /be 2. i", "7"},
+		{`yar s be "ab". s +be "c". s *be 2. s`, "abcabc"},
+		{"yar xs be [1, 2]. xs[1] +be 5. xs[-1] *be 2. xs", "[1, 14]"},
+		{`yar h be {"a": 1}. h["a"] -be 3. h`, `{"a": -2}`},
+		{"chest P|gold be 0|. yar p be P||. p|gold +be 10. p|gold *be 3. p", "P|gold: 30|"},
+		{"yar c be |n: 1|. c|n +be 1. c", "|n: 2|"},
+		{counted + "yar xs be [10]. xs[idx()] +be 5. [xs, len(calls)]", "[[15], 1]"},
+		{counted + "yar rows be [[1]]. yar row be f(): push(calls, 1). gives rows[0]... row()[idx()] +be 1. [rows, len(calls)]",
+			"[[[2]], 2]"},
+		{counted + "yar cs be [|n: 1|]. cs[idx()]|n +be 1. [cs, len(calls)]", "[[|n: 2|], 1]"},
+		{"chest P|gold be 0 if f(g): g >= 0..|. yar p be P||. p|gold -be 1.", "ERROR: invalid value for field gold of P: -1"},
+		{"nope +be 1.", "ERROR: Identifier not found: nope"},
+		{`yar h be {}. h["a"] +be 1.`, "ERROR: type mismatch: MT + INT"},
+		{"yar i be 1. i +be nope.", "ERROR: Identifier not found: nope"},
+		{`yar i be ay. i -be 1.`, "ERROR: type mismatch: BOOL - INT"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	greet := `yar greet be f(name, greeting be "Ahoy", punct be "!"): gives greeting + ", " + name + punct... `
+	sum := "yar sum be f(first, ..rest): yar total be first. 4 x in rest: total +be x.. gives total... "
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{greet + `greet("Jack")`, "Ahoy, Jack!"},
+		{greet + `greet("Jack", "Arr")`, "Arr, Jack!"},
+		{greet + `greet("Jack", punct: "?")`, "Ahoy, Jack?"},
+		{greet + `greet(punct: ".", name: "Anne")`, "Ahoy, Anne."},
+		{sum + "[sum(1), sum(1, 2, 3)]", "[1, 6]"},
+		{"yar rest be f(..xs): xs... [rest(), rest(1, 2)]", "[[], [1, 2]]"},
+		{"yar span be f(a, b be a * 2): [a, b]... [span(3), span(3, 4)]", "[[3, 6], [3, 4]]"},
+		{"yar same be f(a, b be a): [a, b]... same(7)", "[7, 7]"},
+		{"yar fresh be f(xs be []): push(xs, 1). xs... fresh(). fresh()", "[1]"},
+		{"yar g be f(a, b be 1, ..r): [a, b, r]... g(1, 2, 3, 4)", "[1, 2, [3, 4]]"},
+		{"chest P|n|: add be f(by be 1): self|n + by... yar p be P|1|. [p|add(), p|add(by: 5)]", "[2, 6]"},
+		{"chest Adder|n|: call be f(x, times be 1): self|n * times + x... yar a be Adder|2|. a(1, times: 3)", "7"},
+		{"yar g be f(a): a... g(1, 2)", "ERROR: wrong number of arguments to `g`. got=2, expected=1"},
+		{"yar g be f(a, ..r): a... g()", "ERROR: wrong number of arguments to `g`. got=0, expected at least 1"},
+		{"yar g be f(a, b be 1): a... g(1, 2, 3)", "ERROR: wrong number of arguments to `g`. got=3, expected=1 to 2"},
+		{"yar g be f(a, b): a... g(b: 1)", "ERROR: missing argument to `g`: a"},
+		{"yar g be f(a): a... g(1, a: 2)", "ERROR: argument given twice to `g`: a"},
+		{greet + `greet("a", nme: "b")`, "ERROR: unknown argument to `greet`: nme (did you mean name?)"},
+		{"yar g be f(a): a... g(zzz: 1)", "ERROR: unknown argument to `g`: zzz"},
+		{"len(x: 1)", "ERROR: BUILTIN does not take named arguments"},
+		{"yar g be f(a be nope): a... g()", "ERROR: Identifier not found: nope"},
+		{"yar g be f(a): a... g(a: nope)", "ERROR: Identifier not found: nope"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestFunctionIntrospection(t *testing.T) {
+	hail := `yar hail be f(name, greeting be "Ahoy", ..crew): "Greets name and the crew". gives greeting... `
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{hail + "name(hail)", "hail"},
+		{hail + "arity(hail)", "2"},
+		{hail + "doc(hail)", "Greets name and the crew"},
+		{hail + "hail", "f hail(name, greeting be Ahoy, ..crew)"},
+		{"yar g be f(a, b be 2): a... g", "f g(a, b be 2)"},
+		{"f(a): a..", "f(a)"},
+		{"name(f(a): a..)", "MT"},
+		{"doc(f(a): a..)", "MT"},
+		{`yar g be f(): "only a string"... doc(g)`, "MT"},
+		{"yar g be f(): \"\"\"Multi\nline\"\"\". gives 1... doc(g)", "Multi\nline"},
+		{"chest P|n|: add be f(by): self|n + by... yar p be P|1|. name(p|add)", "add"},
+		{"yar g be f(a): a... yar h be g. name(h)", "g"},
+		{"name(len)", "len"},
+		{"chest P|n|. name(P)", "P"},
+		{"flag Color|Red|. [name(Color), name(Color|Red)]", `["Color", "Red"]`},
+		{"name(1)", "ERROR: argument to `name` not supported, got INT"},
+		{"arity(len)", "ERROR: first argument to `arity` must be FUNCTION, got BUILTIN"},
+		{"doc()", "ERROR: wrong number of arguments. got=0, expected=1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
+
+func TestHashMapInsertionOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`yar m be {}. m["z"] be 1. m["a"] be 2. m["m"] be 3. m`, `{"z": 1, "a": 2, "m": 3}`},
+		{`yar m be {"z": 1}. m["a"] be 2. m["b"] be 3. m["z"] be 4. m`, `{"z": 4, "a": 2, "b": 3}`},
+		{`yar m be {"z": 1}. m["a"] be 2. m["b"] be 3. delete(m, "a"). m["a"] be 5. m`, `{"z": 1, "b": 3, "a": 5}`},
+		{`yar m be {"z": 1}. m["a"] be 2. delete(m, "z"). delete(m, "a"). m["q"] be 1. m`, `{"q": 1}`},
+		{`yar m be {"z": 1}. empty(m). m["y"] be 2. m`, `{"y": 2}`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.AsString() != tt.expected {
+			t.Errorf("wrong result for %s. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.AsString())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package evaluator
+
+import "pir-interpreter/object"
+
+// name_f gives the name of a function, builtin, chest type, flag type or flag
+// member. Functions never bound to a name give MT.
+func name_f(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	switch arg := args[0].(type) {
+	case *object.Function:
+		if arg.Name == "" {
+			return MT
+		}
+		return nativeStringToStringObj(arg.Name)
+	case *object.Builtin:
+		return nativeStringToStringObj(arg.Name)
+	case *object.ChestType:
+		return nativeStringToStringObj(arg.Name)
+	case *object.FlagType:
+		return nativeStringToStringObj(arg.Name)
+	case *object.Flag:
+		return nativeStringToStringObj(arg.Name)
+	default:
+		return newEvaluationError("argument to `name` not supported, got %s", typeName(arg))
+	}
+}
+
+// arity counts the parameters of a function, including the ones with a
+// default but not the rest parameter.
+func arity(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	fn, ok := args[0].(*object.Function)
+	if !ok {
+		return argTypeError("arity", 0, object.FUNCTION_OBJ, args[0])
+	}
+	return &object.Int{Value: int64(len(fn.Params))}
+}
+
+// doc gives the doc string of a function, or MT when it has none.
+func doc(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	fn, ok := args[0].(*object.Function)
+	if !ok {
+		return argTypeError("doc", 0, object.FUNCTION_OBJ, args[0])
+	}
+	if fn.Doc == "" {
+		return MT
+	}
+	return nativeStringToStringObj(fn.Doc)
+}
@@ -0,0 +1,110 @@
+package evaluator
+
+import "pir-interpreter/object"
+
+func keys(args ...object.Object) object.Object {
+	return collectPairs("keys", args, func(pair object.KVP) object.Object {
+		return pair.Key
+	})
+}
+
+func values(args ...object.Object) object.Object {
+	return collectPairs("values", args, func(pair object.KVP) object.Object {
+		return pair.Value
+	})
+}
+
+func items(args ...object.Object) object.Object {
+	return collectPairs("items", args, func(pair object.KVP) object.Object {
+		return &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+	})
+}
+
+func has(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	hashMap, err := hashMapArg("has", args, 0)
+	if err != nil {
+		return err
+	}
+	key, err := hashableArg(args, 1)
+	if err != nil {
+		return err
+	}
+	_, ok := hashMap.Get(key)
+	return nativeBoolToBoolObj(ok)
+}
+
+// delete_f removes a key and gives back its value, or MT if it was missing.
+func delete_f(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	hashMap, err := hashMapArg("delete", args, 0)
+	if err != nil {
+		return err
+	}
+	key, err := hashableArg(args, 1)
+	if err != nil {
+		return err
+	}
+	pair, ok := hashMap.Delete(key)
+	if !ok {
+		return MT
+	}
+	return pair.Value
+}
+
+func getOr(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 3, 3); err != nil {
+		return err
+	}
+	hashMap, err := hashMapArg("getOr", args, 0)
+	if err != nil {
+		return err
+	}
+	key, err := hashableArg(args, 1)
+	if err != nil {
+		return err
+	}
+	if pair, ok := hashMap.Get(key); ok {
+		return pair.Value
+	}
+	return args[2]
+}
+
+// merge builds a new hashmap from all arguments. Later values win, keys keep
+// the position they were first seen at.
+func merge(args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return newEvaluationError("wrong number of arguments. got=0, expected at least 1")
+	}
+	merged := object.NewHashMap()
+	for i := range args {
+		hashMap, ok := args[i].(*object.HashMap)
+		if !ok {
+			return newEvaluationError("arguments to `merge` must be HASHMAP, got %s", args[i].Type())
+		}
+		for _, pair := range hashMap.Pairs() {
+			merged.Set(pair.Key.(object.Hashable), pair.Value)
+		}
+	}
+	return merged
+}
+
+func collectPairs(name string, args []object.Object, pick func(object.KVP) object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	hashMap, err := hashMapArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	pairs := hashMap.Pairs()
+	elements := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = pick(pair)
+	}
+	return &object.Array{Elements: elements}
+}
@@ -0,0 +1,294 @@
+package evaluator
+
+import (
+	"pir-interpreter/ast"
+	"pir-interpreter/object"
+	"slices"
+)
+
+// evalMatchStatementNode tries the arms in order. Every arm gets its own
+// namespace nested in ns for the names its pattern binds, so a pattern that
+// only partly matches leaves nothing behind. Setting any other name in the
+// arm sets it in ns, as in a loop body.
+func evalMatchStatementNode(node *ast.MatchStatement, ns *object.Namespace) object.Object {
+	subject := Eval(node.Subject, ns)
+	if object.IsError(subject) {
+		return subject
+	}
+	for _, arm := range node.Arms {
+		armNS := object.NewLoopNamespace(ns, patternNames(arm.Pattern)...)
+		m := &matcher{ns: armNS}
+		matched, err := m.match(arm.Pattern, subject)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armNS)
+			if object.IsError(guard) {
+				return guard
+			}
+			if guard != AY {
+				continue
+			}
+		}
+		return Eval(arm.Body, armNS)
+	}
+	if node.Alternate != nil {
+		return Eval(node.Alternate, ns)
+	}
+	return MT
+}
+
+// patternNames lists the names pattern binds.
+func patternNames(pattern ast.Pattern) []string {
+	var names []string
+	add := func(name *ast.Identifier) {
+		if name != nil && name.Value != "_" {
+			names = append(names, name.Value)
+		}
+	}
+	var walk func(ast.Pattern)
+	walk = func(pattern ast.Pattern) {
+		switch pattern := pattern.(type) {
+		case *ast.BindingPattern:
+			add(pattern.Name)
+		case *ast.RestPattern:
+			add(pattern.Name)
+		case *ast.ArrayPattern:
+			for _, el := range pattern.Elements {
+				walk(el)
+			}
+		case *ast.HashMapPattern:
+			for _, pair := range pattern.Pairs {
+				walk(pair.Value)
+			}
+			if pattern.Rest != nil {
+				add(pattern.Rest.Name)
+			}
+		case *ast.ChestPattern:
+			for _, field := range pattern.Fields {
+				if field.Pattern == nil {
+					add(field.Name)
+				} else {
+					walk(field.Pattern)
+				}
+			}
+		}
+	}
+	walk(pattern)
+	return names
+}
+
+// destructure binds the names in pattern to the parts of value, as `yar`
+// does for `yar [a, b] be pair.`. A value that doesn't fit is an error.
+func destructure(pattern ast.Pattern, value object.Object, ns *object.Namespace) object.Object {
+	m := &matcher{ns: ns, strict: true}
+	_, err := m.match(pattern, value)
+	return err
+}
+
+// matcher binds the names captured by a pattern in ns. A strict matcher
+// reports why a value doesn't fit instead of just not matching.
+type matcher struct {
+	ns     *object.Namespace
+	strict bool
+}
+
+func (m *matcher) fail(format string, a ...interface{}) (bool, object.Object) {
+	if m.strict {
+		return false, newEvaluationError(format, a...)
+	}
+	return false, nil
+}
+
+func (m *matcher) bind(name *ast.Identifier, value object.Object) {
+	if name.Value != "_" {
+		m.ns.Set(name.Value, value)
+	}
+}
+
+func (m *matcher) match(pattern ast.Pattern, value object.Object) (bool, object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.BindingPattern:
+		m.bind(pattern.Name, value)
+		return true, nil
+	case *ast.LiteralPattern:
+		return m.matchValue(pattern.Value, value)
+	case *ast.ValuePattern:
+		return m.matchValue(pattern.Value, value)
+	case *ast.ArrayPattern:
+		return m.matchArray(pattern, value)
+	case *ast.HashMapPattern:
+		return m.matchHashMap(pattern, value)
+	case *ast.ChestPattern:
+		return m.matchChest(pattern, value)
+	default:
+		return false, newEvaluationError("unknown pattern: %s", pattern.String())
+	}
+}
+
+// matchValue compares with = semantics, so chests defining equals decide for
+// themselves, but values of different types simply don't match.
+func (m *matcher) matchValue(expr ast.Expression, value object.Object) (bool, object.Object) {
+	expected := Eval(expr, m.ns)
+	if object.IsError(expected) {
+		return false, expected
+	}
+	equal := false
+	if result, ok := evalChestInfixOperator(value, "=", expected); ok {
+		if object.IsError(result) {
+			return false, result
+		}
+		equal = result == AY
+	} else {
+		equal = object.Equal(value, expected)
+	}
+	if !equal {
+		return m.fail("%s does not match %s", object.Repr(value), object.Repr(expected))
+	}
+	return true, nil
+}
+
+func (m *matcher) matchAll(patterns []ast.Pattern, values []object.Object) (bool, object.Object) {
+	for i, pattern := range patterns {
+		matched, err := m.match(pattern, values[i])
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (m *matcher) matchArray(pattern *ast.ArrayPattern, value object.Object) (bool, object.Object) {
+	arr, ok := value.(*object.Array)
+	if !ok {
+		return m.fail("cannot destructure %s into an array", typeName(value))
+	}
+	restAt := slices.IndexFunc(pattern.Elements, func(el ast.Pattern) bool {
+		_, ok := el.(*ast.RestPattern)
+		return ok
+	})
+	if restAt < 0 {
+		if len(arr.Elements) != len(pattern.Elements) {
+			return m.fail("wrong number of values to destructure. expected=%d, got=%d",
+				len(pattern.Elements), len(arr.Elements))
+		}
+		return m.matchAll(pattern.Elements, arr.Elements)
+	}
+
+	before, after := pattern.Elements[:restAt], pattern.Elements[restAt+1:]
+	restEnd := len(arr.Elements) - len(after)
+	if restEnd < restAt {
+		return m.fail("not enough values to destructure. expected at least %d, got=%d",
+			len(before)+len(after), len(arr.Elements))
+	}
+	if matched, err := m.matchAll(before, arr.Elements[:restAt]); !matched {
+		return false, err
+	}
+	rest := &object.Array{Elements: slices.Clone(arr.Elements[restAt:restEnd])}
+	m.bind(pattern.Elements[restAt].(*ast.RestPattern).Name, rest)
+	return m.matchAll(after, arr.Elements[restEnd:])
+}
+
+// matchHashMap only allows keys the pattern doesn't name when it has a rest
+// element, as matchArray does for extra elements.
+func (m *matcher) matchHashMap(pattern *ast.HashMapPattern, value object.Object) (bool, object.Object) {
+	hashMap, ok := value.(*object.HashMap)
+	if !ok {
+		return m.fail("cannot destructure %s into a hash map", typeName(value))
+	}
+	matchedKeys := object.NewHashMap()
+	for _, pair := range pattern.Pairs {
+		key := Eval(pair.Key, m.ns)
+		if object.IsError(key) {
+			return false, key
+		}
+		hashable, ok := object.AsHashable(key)
+		if !ok {
+			return false, newEvaluationError("Object not hashable. Type=%s", key.Type())
+		}
+		entry, ok := hashMap.Get(hashable)
+		if !ok {
+			return m.fail("key not found: %s", object.Repr(key))
+		}
+		if matched, err := m.match(pair.Value, entry.Value); !matched {
+			return false, err
+		}
+		matchedKeys.Set(hashable, MT)
+	}
+	rest := object.NewHashMap()
+	for _, pair := range hashMap.Pairs() {
+		key := pair.Key.(object.Hashable)
+		if _, ok := matchedKeys.Get(key); ok {
+			continue
+		}
+		if pattern.Rest == nil {
+			return m.fail("unexpected key to destructure: %s", object.Repr(pair.Key))
+		}
+		rest.Set(key, pair.Value)
+	}
+	if pattern.Rest != nil {
+		m.bind(pattern.Rest.Name, rest)
+	}
+	return true, nil
+}
+
+// matchChest matches chests of the pattern's chest type, or any chest when
+// the pattern has no type.
+func (m *matcher) matchChest(pattern *ast.ChestPattern, value object.Object) (bool, object.Object) {
+	var chestType *object.ChestType
+	if pattern.Type != nil {
+		typeObj := Eval(pattern.Type, m.ns)
+		if object.IsError(typeObj) {
+			return false, typeObj
+		}
+		var ok bool
+		chestType, ok = typeObj.(*object.ChestType)
+		if !ok {
+			return false, newEvaluationError("cannot match against %s: not a chest type", typeObj.Type())
+		}
+		for _, field := range pattern.Fields {
+			if _, ok := chestType.Field(field.Name.Value); !ok {
+				return m.unknownField(chestType, field.Name.Value)
+			}
+		}
+	}
+	chest, ok := value.(*object.Chest)
+	if !ok {
+		return m.fail("cannot destructure %s into a chest", typeName(value))
+	}
+	if chestType != nil && (chest.ChestType == nil || !chest.ChestType.Extends(chestType)) {
+		return m.fail("cannot destructure %s into %s", typeName(value), chestType.Name)
+	}
+	for _, field := range pattern.Fields {
+		fieldValue, ok := chest.Items[field.Name.Value]
+		if !ok {
+			if chest.ChestType != nil {
+				return m.unknownField(chest.ChestType, field.Name.Value)
+			}
+			return m.fail("missing field to destructure: %s", field.Name.Value)
+		}
+		if field.Pattern == nil {
+			m.bind(field.Name, fieldValue)
+			continue
+		}
+		if matched, err := m.match(field.Pattern, fieldValue); !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// unknownField only fails the arm of a match, a field the chest type doesn't
+// have is an error when destructuring.
+func (m *matcher) unknownField(chestType *object.ChestType, name string) (bool, object.Object) {
+	if m.strict {
+		return false, unknownFieldError(chestType, name)
+	}
+	return false, nil
+}
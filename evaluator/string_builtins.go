@@ -0,0 +1,216 @@
+package evaluator
+
+import (
+	"pir-interpreter/object"
+	"strings"
+	"unicode/utf8"
+)
+
+func split(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 2); err != nil {
+		return err
+	}
+	str, err := stringArg("split", args, 0)
+	if err != nil {
+		return err
+	}
+	var parts []string
+	if len(args) == 1 {
+		parts = strings.Fields(str)
+	} else {
+		sep, err := stringArg("split", args, 1)
+		if err != nil {
+			return err
+		}
+		parts = strings.Split(str, sep)
+	}
+	return nativeStringsToArrayObj(parts)
+}
+
+func join(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return argTypeError("join", 0, object.ARRAY_OBJ, args[0])
+	}
+	sep := ""
+	if len(args) == 2 {
+		var err object.Object
+		if sep, err = stringArg("join", args, 1); err != nil {
+			return err
+		}
+	}
+	parts := make([]string, len(arr.Elements))
+	for i, el := range arr.Elements {
+		parts[i] = el.AsString()
+	}
+	return nativeStringToStringObj(strings.Join(parts, sep))
+}
+
+func upper(args ...object.Object) object.Object {
+	return mapString("upper", strings.ToUpper, args)
+}
+
+func lower(args ...object.Object) object.Object {
+	return mapString("lower", strings.ToLower, args)
+}
+
+func trim(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 2); err != nil {
+		return err
+	}
+	str, err := stringArg("trim", args, 0)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return nativeStringToStringObj(strings.TrimSpace(str))
+	}
+	cutset, err := stringArg("trim", args, 1)
+	if err != nil {
+		return err
+	}
+	return nativeStringToStringObj(strings.Trim(str, cutset))
+}
+
+func replace(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 3, 4); err != nil {
+		return err
+	}
+	strs, err := stringArgs("replace", args[:3])
+	if err != nil {
+		return err
+	}
+	n := int64(-1)
+	if len(args) == 4 {
+		if n, err = intArg("replace", args, 3); err != nil {
+			return err
+		}
+	}
+	return nativeStringToStringObj(strings.Replace(strs[0], strs[1], strs[2], int(n)))
+}
+
+func contains(args ...object.Object) object.Object {
+	return testStrings("contains", strings.Contains, args)
+}
+
+func startsWith(args ...object.Object) object.Object {
+	return testStrings("startsWith", strings.HasPrefix, args)
+}
+
+func endsWith(args ...object.Object) object.Object {
+	return testStrings("endsWith", strings.HasSuffix, args)
+}
+
+// find gives the code point index of the first occurrence of sub, or -1.
+// For arrays it gives the first element matching a predicate.
+func find(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	if args[0].Type() == object.ARRAY_OBJ {
+		return findElement(args...)
+	}
+	strs, err := stringArgs("find", args)
+	if err != nil {
+		return err
+	}
+	i := strings.Index(strs[0], strs[1])
+	if i < 0 {
+		return nativeIntToIntObj(-1)
+	}
+	return nativeIntToIntObj(int64(utf8.RuneCountInString(strs[0][:i])))
+}
+
+func repeat(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	if _, err := stringArg("repeat", args, 0); err != nil {
+		return err
+	}
+	if _, err := intArg("repeat", args, 1); err != nil {
+		return err
+	}
+	return evalStringRepetition(args[0], args[1])
+}
+
+// pad works like printf widths: a positive width pads on the left, a negative
+// width pads on the right. The fill defaults to a space.
+func pad(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 3); err != nil {
+		return err
+	}
+	str, err := stringArg("pad", args, 0)
+	if err != nil {
+		return err
+	}
+	width, err := intArg("pad", args, 1)
+	if err != nil {
+		return err
+	}
+	fill := " "
+	if len(args) == 3 {
+		if fill, err = stringArg("pad", args, 2); err != nil {
+			return err
+		}
+		if utf8.RuneCountInString(fill) != 1 {
+			return newEvaluationError("fill for `pad` must be a single character, got %q", fill)
+		}
+	}
+	if width > maxStringLength || width < -maxStringLength {
+		return newEvaluationError("width for `pad` too large: %d", width)
+	}
+	missing := int(max(width, -width)) - utf8.RuneCountInString(str)
+	if missing <= 0 {
+		return args[0]
+	}
+	padding := strings.Repeat(fill, missing)
+	if width < 0 {
+		return nativeStringToStringObj(str + padding)
+	}
+	return nativeStringToStringObj(padding + str)
+}
+
+func chars(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	str, err := stringArg("chars", args, 0)
+	if err != nil {
+		return err
+	}
+	return nativeStringsToArrayObj(strings.Split(str, ""))
+}
+
+func mapString(name string, fn func(string) string, args []object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	str, err := stringArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	return nativeStringToStringObj(fn(str))
+}
+
+func testStrings(name string, fn func(string, string) bool, args []object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	strs, err := stringArgs(name, args)
+	if err != nil {
+		return err
+	}
+	return nativeBoolToBoolObj(fn(strs[0], strs[1]))
+}
+
+func nativeStringsToArrayObj(strs []string) object.Object {
+	elements := make([]object.Object, len(strs))
+	for i, s := range strs {
+		elements[i] = nativeStringToStringObj(s)
+	}
+	return &object.Array{Elements: elements}
+}
@@ -0,0 +1,125 @@
+package evaluator
+
+import (
+	"pir-interpreter/object"
+	"strconv"
+)
+
+// typeName is the ObjectType of obj, or the declared type name for chests
+// created from a chest type and for flag members.
+func typeName(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.Chest:
+		if obj.ChestType != nil {
+			return obj.ChestType.Name
+		}
+	case *object.Flag:
+		return obj.FlagType.Name
+	}
+	return string(obj.Type())
+}
+
+func type_f(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	return nativeStringToStringObj(typeName(args[0]))
+}
+
+// isType accepts either the ObjectType or, for chests, the chest type name.
+func isType(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	name, err := stringArg("isType", args, 1)
+	if err != nil {
+		return err
+	}
+	return nativeBoolToBoolObj(name == typeName(args[0]) || name == string(args[0].Type()))
+}
+
+// isA reports whether the chest was made from chestType or from a chest type
+// extending it, or whether a flag member belongs to the given flag type.
+func isA(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 2, 2); err != nil {
+		return err
+	}
+	if flagType, ok := args[1].(*object.FlagType); ok {
+		flag, ok := args[0].(*object.Flag)
+		return nativeBoolToBoolObj(ok && flag.FlagType == flagType)
+	}
+	chestType, ok := args[1].(*object.ChestType)
+	if !ok {
+		return argTypeError("isA", 1, object.CHEST_TYPE_OBJ, args[1])
+	}
+	chest, ok := args[0].(*object.Chest)
+	if !ok || chest.ChestType == nil {
+		return NAY
+	}
+	return nativeBoolToBoolObj(chest.ChestType.Extends(chestType))
+}
+
+func int_f(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	switch arg := args[0].(type) {
+	case *object.Int:
+		return arg
+	case *object.Bool:
+		if arg.Value {
+			return &object.Int{Value: 1}
+		}
+		return &object.Int{Value: 0}
+	case *object.Flag:
+		return &object.Int{Value: int64(arg.Ordinal)}
+	case *object.String:
+		i, err := strconv.ParseInt(arg.Value, 10, 64)
+		if err != nil {
+			return newEvaluationError("cannot convert %s to INT", object.Quote(arg.Value))
+		}
+		return &object.Int{Value: i}
+	default:
+		return newEvaluationError("cannot convert %s to INT", arg.Type())
+	}
+}
+
+func str_f(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	if s, ok := args[0].(*object.String); ok {
+		return s
+	}
+	str, err := displayString(args[0])
+	if err != nil {
+		return err
+	}
+	return nativeStringToStringObj(str)
+}
+
+// bool_f converts INT (zero is nay), the strings "ay" and "nay", and MT,
+// which is nay.
+func bool_f(args ...object.Object) object.Object {
+	if err := checkArgCount(args, 1, 1); err != nil {
+		return err
+	}
+	switch arg := args[0].(type) {
+	case *object.Bool:
+		return arg
+	case *object.Int:
+		return nativeBoolToBoolObj(arg.Value != 0)
+	case *object.MT:
+		return NAY
+	case *object.String:
+		switch arg.Value {
+		case "ay":
+			return AY
+		case "nay":
+			return NAY
+		}
+		return newEvaluationError("cannot convert %s to BOOL", object.Quote(arg.Value))
+	default:
+		return newEvaluationError("cannot convert %s to BOOL", arg.Type())
+	}
+}
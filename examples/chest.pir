@@ -19,3 +19,20 @@ anotherInstance|foo be instance|foo.
 ahoy(anotherInstance).
 
 ahoy(instance|bar()).
+
+$ Methods are declared in a block after the fields. Called through an
+$ instance, they can reach it as self.
+chest Point|x, y|:
+    norm be f():
+        gives self|x * self|x + self|y * self|y.
+    .
+    moveBy be f(dx, dy):
+        self|x be self|x + dx.
+        self|y be self|y + dy.
+    .
+.
+
+yar p be Point|3, 4|.
+ahoy(p|norm()).
+p|moveBy(1, 1).
+ahoy(p).
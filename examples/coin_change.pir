@@ -13,7 +13,7 @@ yar coinChange be
         yar x be 0.
         4 x < amount + 1:
             push(solutions, -1).
-            x be x + 1.
+            x +be 1.
         .
 
         yar i be 1.
@@ -31,9 +31,9 @@ yar coinChange be
                         solutions[i] be min(solutions[i], 1 + solutions[lastSolAmount]).
                     .
                 .
-                j be j + 1.
+                j +be 1.
             .
-            i be i + 1.
+            i +be 1.
         .
         gives solutions[amount].
     .
@@ -47,11 +47,12 @@ yar tests be [
 
 yar t be 0.
 4 t < len(tests):
-    result be coinChange(tests[t][0], tests[t][1]).
-    if result <> tests[t][2]:
-       ahoy("Test " + t + "..." + "FAIL. Expected: " + tests[t][2] + " Got: " + result).
+    yar [coins, amount, expected] be tests[t].
+    result be coinChange(coins, amount).
+    if result <> expected:
+       ahoy("Test " + t + "..." + "FAIL. Expected: " + expected + " Got: " + result).
     ls:
         ahoy("Test " + t + "..." + "PASS").
     .
-    t be t + 1.
+    t +be 1.
 .
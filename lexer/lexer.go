@@ -1,33 +1,52 @@
 // lexer/lexer.go
 package lexer
 
-import "pir-interpreter/token"
+import (
+	"fmt"
+	"pir-interpreter/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
 type Lexer struct {
 	input         string
 	position      int
 	readPosition  int
-	ch            byte
+	ch            rune
 	curLine       int
 	curCharOfLine int
+	errors        []string
 }
 
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+func (l *Lexer) createLexerError(msg string, line, char int) {
+	formatted_message := fmt.Sprintf("%s. Line: %d Char: %d", msg, line, char)
+	l.errors = append(l.errors, formatted_message)
+}
+
+// readChar decodes the next rune of the input, columns are counted in runes.
 func (l *Lexer) readChar() {
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition++
+	l.readPosition += width
 	l.curCharOfLine++
 }
 
-func (l *Lexer) peekNext() byte {
+func (l *Lexer) peekNext() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		r, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+		return r
 	}
 
 }
@@ -40,13 +59,13 @@ func (l *Lexer) NextToken() token.Token {
 
 	switch l.ch {
 	case '+':
-		currentToken = l.newToken(token.PLUS, "+")
+		currentToken = l.newOperatorToken(token.PLUS, token.PLUSBE)
 	case '-':
-		currentToken = l.newToken(token.MINUS, "-")
+		currentToken = l.newOperatorToken(token.MINUS, token.MINUSBE)
 	case '*':
-		currentToken = l.newToken(token.STAR, "*")
+		currentToken = l.newOperatorToken(token.STAR, token.STARBE)
 	case '/':
-		currentToken = l.newToken(token.FSLASH, "/")
+		currentToken = l.newOperatorToken(token.FSLASH, token.FSLASHBE)
 	case '!':
 		currentToken = l.newToken(token.AAAA, "!")
 	case '<':
@@ -90,15 +109,36 @@ func (l *Lexer) NextToken() token.Token {
 		currentToken = l.newToken(token.RBRACKET, "]")
 	case '|':
 		currentToken = l.newToken(token.PIPE, "|")
+	case '?':
+		currentToken = l.newToken(token.QUESTION, "?")
 	case '4':
 		currentToken = l.newToken(token.FOR, "4")
 	case '\'', '"':
-		str := l.readString()
-		currentToken = l.newToken(token.STRING, str)
+		if l.isTripleQuoteAt(l.position) {
+			// Raw strings can span lines, so they sit at the opening quote as well
+			quote := l.newToken(token.STRING, "")
+			currentToken = l.newToken(token.STRING, l.readTripleQuotedString())
+			currentToken.LineNum, currentToken.CharNum = quote.LineNum, quote.CharNum
+		} else {
+			str := l.readString()
+			currentToken = l.newToken(token.STRING, str)
+		}
 	case 0:
 		currentToken = l.newToken(token.EOF, "")
 	default:
-		if isCharLetter(l.ch) {
+		if l.ch == 'f' && isCharQuote(l.peekNext()) {
+			l.readChar()
+			// The f-string body is kept raw, escapes and {holes} are resolved by the
+			// parser. The token sits at the opening quote so the parser can place them.
+			quote := l.newToken(token.FSTRING, "")
+			currentToken = l.newToken(token.FSTRING, l.readRawString())
+			currentToken.LineNum, currentToken.CharNum = quote.LineNum, quote.CharNum
+		} else if l.ch == 'd' && l.isTripleQuoteAt(l.readPosition) {
+			l.readChar()
+			quote := l.newToken(token.STRING, "")
+			currentToken = l.newToken(token.STRING, dedent(l.readTripleQuotedString()))
+			currentToken.LineNum, currentToken.CharNum = quote.LineNum, quote.CharNum
+		} else if isCharLetter(l.ch) {
 			literal := l.readIdentifier()
 			tokType := token.LookupIdent(literal)
 			return l.newToken(tokType, literal)
@@ -113,17 +153,98 @@ func (l *Lexer) NextToken() token.Token {
 	return currentToken
 }
 
+// newOperatorToken gives the compound assignment token when the operator is
+// directly followed by the keyword `be`, as in `x +be 1`.
+func (l *Lexer) newOperatorToken(operator, compound token.TokenType) token.Token {
+	rest := l.input[l.readPosition:]
+	if strings.HasPrefix(rest, "be") {
+		next, _ := utf8.DecodeRuneInString(rest[2:])
+		if !isCharLetter(next) {
+			tok := l.newToken(compound, string(compound))
+			l.readChar()
+			l.readChar()
+			return tok
+		}
+	}
+	return l.newToken(operator, string(operator))
+}
+
 func (l *Lexer) readString() string {
+	line, char := l.curLine, l.curCharOfLine
+	raw := l.readRawString()
+	str, err := Unescape(raw)
+	if err != nil {
+		l.createLexerError(err.Error(), line, char)
+		return raw
+	}
+	return str
+}
+
+// readRawString reads up to the matching end quote, skipping over escaped
+// characters without interpreting them.
+func (l *Lexer) readRawString() string {
 	// Could be either ' or "
 	endQuote := l.ch
+	line, char := l.curLine, l.curCharOfLine
 	l.readChar()
 	start := l.position
-	for l.ch != byte(endQuote) && l.ch != 0 {
+	for l.ch != endQuote {
+		if l.ch == 0 {
+			l.createLexerError("unterminated string", line, char)
+			return l.input[start:l.position]
+		}
+		if l.ch == '\\' && l.peekNext() != 0 {
+			l.readChar()
+		}
+		if l.ch == '\n' {
+			l.newLine()
+		}
 		l.readChar()
 	}
 	return l.input[start:l.position]
 }
 
+func (l *Lexer) isTripleQuoteAt(pos int) bool {
+	if pos+3 > len(l.input) || !isCharQuote(rune(l.input[pos])) {
+		return false
+	}
+	return l.input[pos+1] == l.input[pos] && l.input[pos+2] == l.input[pos]
+}
+
+// readTripleQuotedString reads a raw multi-line string. Backslashes and
+// newlines are kept verbatim, only a newline right after the opening quotes
+// is dropped.
+func (l *Lexer) readTripleQuotedString() string {
+	delim := l.input[l.position : l.position+3]
+	line, char := l.curLine, l.curCharOfLine
+	l.readChar()
+	l.readChar()
+	l.readChar()
+	start := l.position
+	for !strings.HasPrefix(l.input[l.position:], delim) {
+		if l.ch == 0 {
+			l.createLexerError("unterminated raw string", line, char)
+			return l.input[start:l.position]
+		}
+		if l.ch == '\n' {
+			l.newLine()
+		}
+		l.readChar()
+	}
+	raw := l.input[start:l.position]
+	// Leave the last quote as the current char for NextToken to step over
+	l.readChar()
+	l.readChar()
+	if strings.HasPrefix(raw, "\r\n") {
+		return raw[2:]
+	}
+	return strings.TrimPrefix(raw, "\n")
+}
+
+func isCharQuote(ch rune) bool {
+	return ch == '\'' || ch == '"'
+}
+
 func (l *Lexer) ignoreComment() {
 	if l.ch == '$' {
 		for l.ch != '\n' {
@@ -137,13 +258,17 @@ func (l *Lexer) ignoreComment() {
 func (l *Lexer) ignoreWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		if l.ch == '\n' {
-			l.curLine += 1
-			l.curCharOfLine = 1
+			l.newLine()
 		}
 		l.readChar()
 	}
 }
 
+func (l *Lexer) newLine() {
+	l.curLine += 1
+	l.curCharOfLine = 1
+}
+
 func (l *Lexer) readIdentifier() string {
 	firstIndex := l.position
 	for isCharLetter(l.ch) {
@@ -153,8 +278,9 @@ func (l *Lexer) readIdentifier() string {
 
 }
 
-func isCharLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isCharLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
 func (l *Lexer) readNumber() string {
@@ -165,7 +291,7 @@ func (l *Lexer) readNumber() string {
 	return l.input[firstIndex:l.position]
 }
 
-func isCharNumber(ch byte) bool {
+func isCharNumber(ch rune) bool {
 	return ch >= '0' && ch <= '9'
 }
 
@@ -179,7 +305,13 @@ func (l *Lexer) newToken(tokenType token.TokenType, literal string) token.Token
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input, curLine: 1, curCharOfLine: 1}
+	return NewAt(input, 1, 1)
+}
+
+// NewAt lexes input found at line and char of a larger source, such as an
+// expression inside an f-string, so its tokens carry their real position.
+func NewAt(input string, line, char int) *Lexer {
+	l := &Lexer{input: input, curLine: line, curCharOfLine: char}
 	l.readChar()
 	return l
 }
@@ -152,3 +152,224 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+	}{
+		{`"a\nb"`, "a\nb"},
+		{`'tab\there'`, "tab\there"},
+		{`"back\\slash"`, `back\slash`},
+		{`"say \"ay\""`, `say "ay"`},
+		{`'it\'s'`, "it's"},
+		{`"é"`, "é"},
+		{`"\u{1F99C}"`, "🦜"},
+	}
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != token.STRING {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q",
+				i, token.STRING, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Fatalf("tests[%d] - unexpected errors: %v", i, l.Errors())
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`"never ends`, "unterminated string. Line: 1 Char: 2"},
+		{`yar x be 'a\qb'.`, `invalid escape sequence: \q. Line: 1 Char: 11`},
+		{`"\u{110000}"`, `invalid unicode escape sequence: \u110000. Line: 1 Char: 2`},
+		{`"\u12"`, `invalid unicode escape sequence: \u. Line: 1 Char: 2`},
+	}
+	for i, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+		if len(l.Errors()) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%v", i, l.Errors())
+		}
+		if l.Errors()[0] != tt.expectedError {
+			t.Fatalf("tests[%d] - error wrong. expected: %q, got: %q",
+				i, tt.expectedError, l.Errors()[0])
+		}
+	}
+}
+
+func TestInterpolatedStringToken(t *testing.T) {
+	input := `f"ay {name}\n" f 'x'`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FSTRING, `ay {name}\n`},
+		{token.F, "f"},
+		{token.STRING, "x"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTripleQuotedStrings(t *testing.T) {
+	input := `"""raw \n {"k": 1}"""
+'''
+it's "fine"'''
+yar text be d"""
+    Report:
+      total: 3
+
+    done
+    """.
+after`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{token.STRING, `raw \n {"k": 1}`, 1},
+		{token.STRING, "it's \"fine\"", 2},
+		{token.YAR, "yar", 4},
+		{token.IDENT, "text", 4},
+		{token.BE, "be", 4},
+		{token.STRING, "Report:\n  total: 3\n\ndone\n", 4},
+		{token.PERIOD, ".", 9},
+		{token.IDENT, "after", 10},
+		{token.EOF, "", 10},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.LineNum != tt.expectedLine {
+			t.Fatalf("tests[%d] - line wrong. expected: %d, got: %d",
+				i, tt.expectedLine, tok.LineNum)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestUnterminatedTripleQuotedString(t *testing.T) {
+	l := New("yar x be \"\"\"never\nends")
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+	expected := "unterminated raw string. Line: 1 Char: 11"
+	if len(l.Errors()) != 1 || l.Errors()[0] != expected {
+		t.Fatalf("expected error %q, got=%v", expected, l.Errors())
+	}
+}
+
+func TestTripleQuotedStringPosition(t *testing.T) {
+	l := New("yar x be \"\"\"a\nb\"\"\". yar y be d'''\n  c\n  '''.")
+	var raws []token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.STRING {
+			raws = append(raws, tok)
+		}
+	}
+	expected := [][2]int{{1, 11}, {2, 18}}
+	if len(raws) != len(expected) {
+		t.Fatalf("expected %d strings, got=%d", len(expected), len(raws))
+	}
+	for i, tok := range raws {
+		if tok.LineNum != expected[i][0] || tok.CharNum != expected[i][1] {
+			t.Errorf("raws[%d] - position wrong. expected: %d:%d, got: %d:%d",
+				i, expected[i][0], expected[i][1], tok.LineNum, tok.CharNum)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := `yar año be "ñ". schätze + π. ✗`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedChar    int
+	}{
+		{token.YAR, "yar", 5},
+		{token.IDENT, "año", 9},
+		{token.BE, "be", 12},
+		{token.STRING, "ñ", 15},
+		{token.PERIOD, ".", 16},
+		{token.IDENT, "schätze", 25},
+		{token.PLUS, "+", 26},
+		{token.IDENT, "π", 29},
+		{token.PERIOD, ".", 29},
+		{token.ILLICIT, "✗", 31},
+		{token.EOF, "", 32},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.CharNum != tt.expectedChar {
+			t.Fatalf("tests[%d] - char wrong. expected: %d, got: %d",
+				i, tt.expectedChar, tok.CharNum)
+		}
+	}
+}
+
+func TestCompoundAssignmentTokens(t *testing.T) {
+	input := `x +be 1. x -be y. x *be 2. x /be 2. x + bee. x -beard`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"}, {token.PLUSBE, "+be"}, {token.INT, "1"}, {token.PERIOD, "."},
+		{token.IDENT, "x"}, {token.MINUSBE, "-be"}, {token.IDENT, "y"}, {token.PERIOD, "."},
+		{token.IDENT, "x"}, {token.STARBE, "*be"}, {token.INT, "2"}, {token.PERIOD, "."},
+		{token.IDENT, "x"}, {token.FSLASHBE, "/be"}, {token.INT, "2"}, {token.PERIOD, "."},
+		{token.IDENT, "x"}, {token.PLUS, "+"}, {token.IDENT, "bee"}, {token.PERIOD, "."},
+		{token.IDENT, "x"}, {token.MINUS, "-"}, {token.IDENT, "beard"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
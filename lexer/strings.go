@@ -0,0 +1,107 @@
+package lexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Unescape resolves the escape sequences in the raw body of a string literal.
+// Supported: \n \t \r \0 \\ \" \' \uXXXX and \u{X...}
+func Unescape(raw string) (string, error) {
+	if !strings.ContainsRune(raw, '\\') {
+		return raw, nil
+	}
+	var out strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			out.WriteByte(raw[i])
+			continue
+		}
+		i++
+		if i >= len(raw) {
+			return "", fmt.Errorf("unfinished escape sequence")
+		}
+		switch raw[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case '0':
+			out.WriteByte(0)
+		case '\\', '"', '\'':
+			out.WriteByte(raw[i])
+		case 'u':
+			r, width, err := readUnicodeEscape(raw[i+1:])
+			if err != nil {
+				return "", err
+			}
+			out.WriteRune(r)
+			i += width
+		default:
+			return "", fmt.Errorf("invalid escape sequence: \\%c", raw[i])
+		}
+	}
+	return out.String(), nil
+}
+
+// readUnicodeEscape decodes the part of a \u escape following the 'u' and
+// reports how many bytes it used.
+func readUnicodeEscape(s string) (rune, int, error) {
+	var digits string
+	var width int
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return 0, 0, fmt.Errorf("unclosed unicode escape sequence")
+		}
+		digits = s[1:end]
+		width = end + 1
+	} else if len(s) >= 4 {
+		digits = s[:4]
+		width = 4
+	}
+	if len(digits) == 0 || len(digits) > 6 {
+		return 0, 0, fmt.Errorf("invalid unicode escape sequence: \\u%s", digits)
+	}
+	code, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return 0, 0, fmt.Errorf("invalid unicode escape sequence: \\u%s", digits)
+	}
+	return rune(code), width, nil
+}
+
+// dedent removes the indentation shared by all non-blank lines. A last line
+// holding only whitespace (the closing quotes on their own line) is emptied.
+func dedent(s string) string {
+	lines := strings.Split(s, "\n")
+	if last := len(lines) - 1; strings.TrimSpace(lines[last]) == "" {
+		lines[last] = ""
+	}
+	var prefix string
+	found := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			prefix, found = indent, true
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = strings.TrimPrefix(line, prefix)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,76 @@
+package object
+
+// Equal reports whether a and b are structurally equal. Arrays, hashmaps and
+// chests are compared by content, chests only when they were made from the
+// same chest type (or are both literals). Everything else that isn't a plain value
+// (functions, builtins, chest types) only equals itself. Containers that
+// contain themselves are handled by treating a pair that is already being
+// compared as equal.
+func Equal(a, b Object) bool {
+	return equal(a, b, make(map[[2]Object]bool))
+}
+
+func equal(a, b Object, comparing map[[2]Object]bool) bool {
+	if a == b {
+		return true
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a := a.(type) {
+	case *Int:
+		return a.Value == b.(*Int).Value
+	case *Bool:
+		return a.Value == b.(*Bool).Value
+	case *String:
+		return a.Value == b.(*String).Value
+	case *MT:
+		return true
+	}
+
+	pair := [2]Object{a, b}
+	if comparing[pair] {
+		return true
+	}
+	comparing[pair] = true
+
+	switch a := a.(type) {
+	case *Array:
+		other := b.(*Array)
+		if len(a.Elements) != len(other.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !equal(a.Elements[i], other.Elements[i], comparing) {
+				return false
+			}
+		}
+		return true
+	case *HashMap:
+		other := b.(*HashMap)
+		if a.Len() != other.Len() {
+			return false
+		}
+		for _, pair := range a.Pairs() {
+			otherPair, ok := other.Get(pair.Key.(Hashable))
+			if !ok || !equal(pair.Value, otherPair.Value, comparing) {
+				return false
+			}
+		}
+		return true
+	case *Chest:
+		other := b.(*Chest)
+		if a.ChestType != other.ChestType || len(a.Items) != len(other.Items) {
+			return false
+		}
+		for name, v := range a.Items {
+			ov, ok := other.Items[name]
+			if !ok || !equal(v, ov, comparing) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,152 @@
+package object
+
+import (
+	"hash/fnv"
+	"io"
+	"sort"
+)
+
+type Hashable interface {
+	Object
+	Hash() HashKey
+}
+
+// HashKey picks the HashMap bucket for a key. Different keys can share a
+// HashKey, so buckets still compare the keys themselves with Equal.
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+func (b *Bool) Hash() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	} else {
+		value = 0
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (i *Int) Hash() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (s *String) Hash() HashKey {
+	if s.hash == nil {
+		h := fnv.New64a()
+		h.Write([]byte(s.Value))
+		s.hash = &HashKey{Type: s.Type(), Value: h.Sum64()}
+	}
+	return *s.hash
+}
+
+// Hash combines the hashes of the elements. Only arrays accepted by
+// AsHashable should be used as keys, which also keeps cycles out of here.
+func (ao *Array) Hash() HashKey {
+	h := fnv.New64a()
+	for _, e := range ao.Elements {
+		writeHash(h, e)
+	}
+	return HashKey{Type: ao.Type(), Value: h.Sum64()}
+}
+
+// Hash combines the field names and the hashes of their values, independent
+// of field order. Only chests accepted by AsHashable should be used as keys,
+// which also keeps cycles out of here.
+func (t *Chest) Hash() HashKey {
+	names := make([]string, 0, len(t.Items))
+	for name := range t.Items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := fnv.New64a()
+	for _, name := range names {
+		h.Write([]byte(name))
+		writeHash(h, t.Items[name])
+	}
+	return HashKey{Type: t.Type(), Value: h.Sum64()}
+}
+
+func (fl *Flag) Hash() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(fl.FlagType.Name))
+	return HashKey{Type: fl.Type(), Value: h.Sum64() + uint64(fl.Ordinal)}
+}
+
+func writeHash(h io.Writer, obj Object) {
+	h.Write([]byte(obj.Type()))
+	hashable, ok := obj.(Hashable)
+	if !ok {
+		return
+	}
+	key := hashable.Hash()
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = byte(key.Value >> (8 * i))
+	}
+	h.Write(buf[:])
+}
+
+// AsHashable reports whether obj can be used as a HashMap key. Arrays and
+// chests only qualify when everything inside them does, and never when they
+// contain themselves.
+func AsHashable(obj Object) (Hashable, bool) {
+	return asHashable(obj, make(map[Object]bool))
+}
+
+func asHashable(obj Object, visiting map[Object]bool) (Hashable, bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		if visiting[obj] {
+			return nil, false
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		for _, e := range obj.Elements {
+			if _, ok := asHashable(e, visiting); !ok {
+				return nil, false
+			}
+		}
+		return obj, true
+	case *Chest:
+		if visiting[obj] {
+			return nil, false
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		for _, v := range obj.Items {
+			if _, ok := asHashable(v, visiting); !ok {
+				return nil, false
+			}
+		}
+		return obj, true
+	case Hashable:
+		return obj, true
+	default:
+		return nil, false
+	}
+}
+
+// freezeKey copies arrays and chests so that mutating the original after it
+// was used as a key can't move the entry to the wrong bucket. Keys have been
+// through AsHashable, so they hold no cycles.
+func freezeKey(key Object) Object {
+	switch key := key.(type) {
+	case *Array:
+		elements := make([]Object, len(key.Elements))
+		for i, e := range key.Elements {
+			elements[i] = freezeKey(e)
+		}
+		return &Array{Elements: elements}
+	case *Chest:
+		chest := NewChest()
+		chest.ChestType = key.ChestType
+		for _, name := range key.Fields() {
+			chest.Set(name, freezeKey(key.Items[name]))
+		}
+		return chest
+	default:
+		return key
+	}
+}
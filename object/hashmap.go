@@ -0,0 +1,112 @@
+package object
+
+type KVP struct {
+	Key   Object
+	Value Object
+}
+
+// HashMap keeps its pairs in insertion order. Entries are linked together so
+// that lookups, inserts and deletes stay O(1). Keys whose hashes collide share
+// a bucket and are told apart by comparing the keys themselves.
+type HashMap struct {
+	buckets map[HashKey][]*hashMapEntry
+	size    int
+	head    *hashMapEntry
+	tail    *hashMapEntry
+}
+
+type hashMapEntry struct {
+	KVP
+	prev *hashMapEntry
+	next *hashMapEntry
+}
+
+func NewHashMap() *HashMap {
+	return &HashMap{buckets: make(map[HashKey][]*hashMapEntry)}
+}
+
+func (h *HashMap) Type() ObjectType { return HASHMAP_OBJ }
+
+func (h *HashMap) AsString() string { return Repr(h) }
+
+func (h *HashMap) find(key Hashable) (HashKey, int) {
+	hashKey := key.Hash()
+	for i, entry := range h.buckets[hashKey] {
+		if Equal(entry.Key, key) {
+			return hashKey, i
+		}
+	}
+	return hashKey, -1
+}
+
+func (h *HashMap) Get(key Hashable) (KVP, bool) {
+	hashKey, i := h.find(key)
+	if i < 0 {
+		return KVP{}, false
+	}
+	return h.buckets[hashKey][i].KVP, true
+}
+
+// Set stores value under key. Overwriting a key keeps its original position.
+func (h *HashMap) Set(key Hashable, value Object) {
+	hashKey, i := h.find(key)
+	if i >= 0 {
+		h.buckets[hashKey][i].Value = value
+		return
+	}
+	entry := &hashMapEntry{KVP: KVP{Key: freezeKey(key), Value: value}, prev: h.tail}
+	if h.tail != nil {
+		h.tail.next = entry
+	} else {
+		h.head = entry
+	}
+	h.tail = entry
+	h.buckets[hashKey] = append(h.buckets[hashKey], entry)
+	h.size++
+}
+
+func (h *HashMap) Delete(key Hashable) (KVP, bool) {
+	hashKey, i := h.find(key)
+	if i < 0 {
+		return KVP{}, false
+	}
+	bucket := h.buckets[hashKey]
+	entry := bucket[i]
+	if entry.prev != nil {
+		entry.prev.next = entry.next
+	} else {
+		h.head = entry.next
+	}
+	if entry.next != nil {
+		entry.next.prev = entry.prev
+	} else {
+		h.tail = entry.prev
+	}
+	if len(bucket) == 1 {
+		delete(h.buckets, hashKey)
+	} else {
+		h.buckets[hashKey] = append(bucket[:i:i], bucket[i+1:]...)
+	}
+	h.size--
+	return entry.KVP, true
+}
+
+func (h *HashMap) Len() int {
+	return h.size
+}
+
+func (h *HashMap) Clear() {
+	h.buckets = make(map[HashKey][]*hashMapEntry)
+	h.size = 0
+	h.head = nil
+	h.tail = nil
+}
+
+// Pairs returns the entries in insertion order.
+func (h *HashMap) Pairs() []KVP {
+	pairs := make([]KVP, 0, h.size)
+	for entry := h.head; entry != nil; entry = entry.next {
+		pairs = append(pairs, entry.KVP)
+	}
+	return pairs
+}
@@ -11,9 +11,21 @@ func NewNestedNamespace(ns *Namespace) *Namespace {
 	return nestedNS
 }
 
+// NewLoopNamespace gives a namespace for one pass of a loop body. Only the
+// names in own live in it, setting any other name sets it in ns.
+func NewLoopNamespace(ns *Namespace, own ...string) *Namespace {
+	loopNS := NewNestedNamespace(ns)
+	loopNS.own = make(map[string]bool, len(own))
+	for _, name := range own {
+		loopNS.own[name] = true
+	}
+	return loopNS
+}
+
 type Namespace struct {
 	binds  map[string]Object
 	parent *Namespace
+	own    map[string]bool // for loop namespaces, the names kept here
 }
 
 func (ns *Namespace) Get(name string) (Object, bool) {
@@ -25,6 +37,9 @@ func (ns *Namespace) Get(name string) (Object, bool) {
 }
 
 func (ns *Namespace) Set(name string, val Object) Object {
+	if ns.own != nil && !ns.own[name] {
+		return ns.parent.Set(name, val)
+	}
 	ns.binds[name] = val
 	return val
 }
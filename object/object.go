@@ -3,7 +3,6 @@ package object
 import (
 	"bytes"
 	"fmt"
-	"hash/fnv"
 	"pir-interpreter/ast"
 	"strings"
 )
@@ -23,6 +22,8 @@ const (
 	BREAK_OBJ       = "BREAK"
 	CHEST_TYPE_OBJ  = "CHEST_TYPE"
 	CHEST_OBJ       = "CHEST"
+	FLAG_TYPE_OBJ   = "FLAG_TYPE"
+	FLAG_OBJ        = "FLAG"
 )
 
 type ObjectType string
@@ -90,29 +91,34 @@ func IsError(obj Object) bool {
 }
 
 type Function struct {
-	Params []*ast.Identifier
-	Body   *ast.BlockStatement
-	NS     *Namespace
+	Name     string // set when the literal is bound with yar or is a chest method
+	Doc      string // the string the body starts with, if any
+	Params   []*ast.Identifier
+	Defaults map[string]ast.Expression // evaluated in the call's namespace when left out
+	Rest     *ast.Identifier           // collects extra arguments into an array, or nil
+	Body     *ast.BlockStatement
+	NS       *Namespace
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+
+// AsString gives the signature only, e.g. `f span(a, b be (a * 2), ..rest)`.
 func (f *Function) AsString() string {
 	var out bytes.Buffer
-	params := []string{}
-	for _, p := range f.Params {
-		params = append(params, p.String())
-	}
+	params := ast.ParamStrings(f.Params, f.Defaults, f.Rest)
 	out.WriteString("f")
+	if f.Name != "" {
+		out.WriteString(" " + f.Name)
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") :\n")
-	out.WriteString(f.Body.String())
-	out.WriteString("\n.")
+	out.WriteString(")")
 	return out.String()
 }
 
 type String struct {
 	Value string
+	hash  *HashKey // computed on first use, strings are never mutated
 }
 
 func (s *String) Type() ObjectType { return STRING_OBJ }
@@ -121,7 +127,8 @@ func (s *String) AsString() string { return s.Value }
 type BuiltinFunction func(args ...Object) Object
 
 type Builtin struct {
-	Fn BuiltinFunction
+	Name string
+	Fn   BuiltinFunction
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
@@ -132,103 +139,126 @@ type Array struct {
 }
 
 func (ao *Array) Type() ObjectType { return ARRAY_OBJ }
-func (ao *Array) AsString() string {
-	var out bytes.Buffer
-	elements := []string{}
-	for _, e := range ao.Elements {
-		elements = append(elements, e.AsString())
-	}
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-	return out.String()
-}
 
-type Hashable interface {
-	Hash() HashKey
+// AsString quotes the strings inside the array, see Repr.
+func (ao *Array) AsString() string { return Repr(ao) }
+
+type Chest struct {
+	Items     map[string]Object
+	ChestType *ChestType // nil for chest literals
+	fields    []string   // Items keys in the order they were first set
+
+	formatting bool // set while Format runs the chest's own format
 }
 
-type HashKey struct {
-	Type  ObjectType
-	Value uint64
+func NewChest() *Chest {
+	return &Chest{Items: make(map[string]Object)}
 }
 
-func (b *Bool) Hash() HashKey {
-	var value uint64
-	if b.Value {
-		value = 1
-	} else {
-		value = 0
+func (t *Chest) Type() ObjectType { return CHEST_OBJ }
+
+func (t *Chest) Set(name string, val Object) {
+	if _, ok := t.Items[name]; !ok {
+		t.fields = append(t.fields, name)
 	}
-	return HashKey{Type: b.Type(), Value: value}
+	t.Items[name] = val
 }
 
-func (i *Int) Hash() HashKey {
-	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+// Fields returns the field names in the order they were first set.
+func (t *Chest) Fields() []string {
+	return t.fields
 }
 
-func (s *String) Hash() HashKey {
-	h := fnv.New64a()
-	h.Write([]byte(s.Value))
-	return HashKey{Type: s.Type(), Value: h.Sum64()}
-}
+func (t *Chest) AsString() string { return Repr(t) }
 
-type KVP struct {
-	Key   Object
-	Value Object
+// ChestField describes one field of a chest type.
+type ChestField struct {
+	Name      string
+	Default   ast.Expression // evaluated for every instance leaving the field out
+	NS        *Namespace     // where Default is evaluated
+	Optional  bool           // leaving the field out makes it MT
+	Validator Object         // gives ay for values the field accepts, or nil
 }
 
-type HashMap struct {
-	MP map[HashKey]KVP
+type ChestType struct {
+	Name    string
+	Parent  *ChestType // the chest type this one extends, or nil
+	Fields  []*ChestField
+	Methods map[string]*Function
 }
 
-func (h *HashMap) Type() ObjectType { return HASHMAP_OBJ }
+func (ct *ChestType) Type() ObjectType { return CHEST_TYPE_OBJ }
 
-func (h *HashMap) AsString() string {
+func (ct *ChestType) AsString() string {
 	var out bytes.Buffer
-	pairs := []string{}
-	for _, pair := range h.MP {
-		pairs = append(pairs, fmt.Sprintf("%s: %s",
-			pair.Key.AsString(), pair.Value.AsString()))
-	}
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+	out.WriteString("chest ")
+	out.WriteString(ct.Name)
+	out.WriteString("|")
+	out.WriteString(strings.Join(ct.FieldNames(), ", "))
+	out.WriteString("|")
 	return out.String()
 }
 
-type Chest struct {
-	Items map[string]Object
+func (ct *ChestType) Field(name string) (*ChestField, bool) {
+	for _, f := range ct.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return nil, false
 }
 
-func (t *Chest) Type() ObjectType { return CHEST_OBJ }
-
-func (t *Chest) AsString() string {
-	var out bytes.Buffer
-	pairs := []string{}
-	for id, obj := range t.Items {
-		val := obj.AsString()
-		if obj.Type() == STRING_OBJ {
-			val = fmt.Sprintf("\"%s\"", val)
+// Extends reports whether ct is other or was built from it.
+func (ct *ChestType) Extends(other *ChestType) bool {
+	for t := ct; t != nil; t = t.Parent {
+		if t == other {
+			return true
 		}
-		pairs = append(pairs, fmt.Sprintf("%s: %s", id, val))
 	}
-	out.WriteString("|")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("|")
-	return out.String()
+	return false
 }
 
-type ChestType struct {
-	Fields []string
+func (ct *ChestType) FieldNames() []string {
+	names := make([]string, len(ct.Fields))
+	for i, f := range ct.Fields {
+		names[i] = f.Name
+	}
+	return names
 }
 
-func (ct *ChestType) Type() ObjectType { return CHEST_TYPE_OBJ }
+// FlagType is declared by a flag statement and holds its members in
+// declaration order.
+type FlagType struct {
+	Name    string
+	Members []*Flag
+}
 
-func (ct *ChestType) AsString() string {
-	var out bytes.Buffer
-	out.WriteString("chest|")
-	out.WriteString(strings.Join(ct.Fields, ", "))
-	out.WriteString("|")
-	return out.String()
+func (ft *FlagType) Type() ObjectType { return FLAG_TYPE_OBJ }
+
+func (ft *FlagType) AsString() string {
+	names := make([]string, len(ft.Members))
+	for i, m := range ft.Members {
+		names[i] = m.Name
+	}
+	return "flag " + ft.Name + "|" + strings.Join(names, ", ") + "|"
+}
+
+func (ft *FlagType) Member(name string) (*Flag, bool) {
+	for _, m := range ft.Members {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return nil, false
 }
+
+// Flag is a member of a flag type. Every member exists once, so members are
+// compared by identity.
+type Flag struct {
+	FlagType *FlagType
+	Name     string
+	Ordinal  int // position in the flag statement, starting at 0
+}
+
+func (fl *Flag) Type() ObjectType { return FLAG_OBJ }
+func (fl *Flag) AsString() string { return fl.FlagType.Name + "|" + fl.Name }
@@ -2,6 +2,22 @@ package object
 
 import "testing"
 
+var (
+	_ Indexable = &Array{}
+	_ Indexable = &String{}
+	_ Indexable = &HashMap{}
+	_ Sized     = &Array{}
+	_ Sized     = &String{}
+	_ Sized     = &HashMap{}
+	_ Iterable  = &Array{}
+	_ Iterable  = &String{}
+	_ Iterable  = &HashMap{}
+	_ Sized     = &FlagType{}
+	_ Iterable  = &FlagType{}
+	_ Hashable  = &Flag{}
+	_ Callable  = &Builtin{}
+)
+
 func TestStringHashKey(t *testing.T) {
 	u1 := &String{Value: "whatup"}
 	u2 := &String{Value: "whatup"}
@@ -18,3 +34,140 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("Hash collision on unique inputs")
 	}
 }
+
+func TestHashMapKeepsInsertionOrder(t *testing.T) {
+	h := NewHashMap()
+	for _, k := range []string{"c", "a", "b", "d"} {
+		h.Set(&String{Value: k}, &Int{Value: 1})
+	}
+	h.Set(&String{Value: "a"}, &Int{Value: 2})
+	h.Delete(&String{Value: "c"})
+	h.Delete(&String{Value: "d"})
+	h.Set(&String{Value: "c"}, &Int{Value: 3})
+
+	expected := `{"a": 2, "b": 1, "c": 3}`
+	if h.AsString() != expected {
+		t.Errorf("wrong order. expected=%q, got=%q", expected, h.AsString())
+	}
+	if h.Len() != 3 {
+		t.Errorf("wrong length. expected=3, got=%d", h.Len())
+	}
+	if _, ok := h.Delete(&String{Value: "missing"}); ok {
+		t.Errorf("deleting a missing key reported success")
+	}
+}
+
+// collidingKey hashes every value to the same bucket.
+type collidingKey struct {
+	String
+}
+
+func (c *collidingKey) Hash() HashKey {
+	return HashKey{Type: STRING_OBJ, Value: 42}
+}
+
+func TestHashMapCollisions(t *testing.T) {
+	h := NewHashMap()
+	a := &collidingKey{String{Value: "a"}}
+	b := &collidingKey{String{Value: "b"}}
+	h.Set(a, &Int{Value: 1})
+	h.Set(b, &Int{Value: 2})
+	if h.Len() != 2 {
+		t.Fatalf("colliding keys overwrote each other. len=%d", h.Len())
+	}
+	if pair, ok := h.Get(a); !ok || pair.Value.(*Int).Value != 1 {
+		t.Errorf("wrong value for first colliding key. got=%v", pair.Value)
+	}
+	if pair, ok := h.Get(b); !ok || pair.Value.(*Int).Value != 2 {
+		t.Errorf("wrong value for second colliding key. got=%v", pair.Value)
+	}
+	h.Delete(a)
+	if _, ok := h.Get(b); !ok {
+		t.Errorf("deleting a colliding key removed its neighbour")
+	}
+	if _, ok := h.Get(a); ok {
+		t.Errorf("deleted key is still present")
+	}
+}
+
+func TestCompositeHashKeys(t *testing.T) {
+	arr := func(values ...int64) *Array {
+		elements := []Object{}
+		for _, v := range values {
+			elements = append(elements, &Int{Value: v})
+		}
+		return &Array{Elements: elements}
+	}
+	if arr(1, 2).Hash() != arr(1, 2).Hash() {
+		t.Errorf("equal arrays do not hash the same")
+	}
+	if arr(1, 2).Hash() == arr(2, 1).Hash() {
+		t.Errorf("array hash ignores element order")
+	}
+
+	c1 := NewChest()
+	c1.Set("x", &Int{Value: 1})
+	c1.Set("y", &String{Value: "a"})
+	c2 := NewChest()
+	c2.Set("y", &String{Value: "a"})
+	c2.Set("x", &Int{Value: 1})
+	if c1.Hash() != c2.Hash() {
+		t.Errorf("chests with the same fields do not hash the same")
+	}
+
+	if _, ok := AsHashable(&Array{Elements: []Object{arr(1), &HashMap{}}}); ok {
+		t.Errorf("array holding a hashmap should not be hashable")
+	}
+
+	cyclic := arr(1)
+	cyclic.Elements = append(cyclic.Elements, cyclic)
+	if _, ok := AsHashable(cyclic); ok {
+		t.Errorf("array holding itself should not be hashable")
+	}
+
+	h := NewHashMap()
+	key := arr(1, 2)
+	h.Set(key, &Int{Value: 1})
+	key.Elements[0] = &Int{Value: 5}
+	if _, ok := h.Get(arr(1, 2)); !ok {
+		t.Errorf("mutating an array after using it as a key lost the entry")
+	}
+}
+
+func TestIterators(t *testing.T) {
+	collect := func(it Iterator) []string {
+		out := []string{}
+		for {
+			el, ok := it.Next()
+			if !ok {
+				return out
+			}
+			out = append(out, el.AsString())
+		}
+	}
+	h := NewHashMap()
+	h.Set(&String{Value: "b"}, &Int{Value: 1})
+	h.Set(&String{Value: "a"}, &Int{Value: 2})
+	tests := []struct {
+		iterable Iterable
+		expected []string
+	}{
+		{&Array{Elements: []Object{&Int{Value: 1}, &Int{Value: 2}}}, []string{"1", "2"}},
+		{&String{Value: "añ"}, []string{"a", "ñ"}},
+		{h, []string{"b", "a"}},
+		{&Array{}, []string{}},
+	}
+	for _, tt := range tests {
+		got := collect(tt.iterable.Iter())
+		if len(got) != len(tt.expected) {
+			t.Errorf("wrong elements. expected=%v, got=%v", tt.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("wrong elements. expected=%v, got=%v", tt.expected, got)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package object
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Indexable values support `value[index]`. Index gives an *Error for an index
+// the value can't use, and nil when nothing is stored under a valid one.
+type Indexable interface {
+	Object
+	Index(index Object) Object
+}
+
+// Sized values have a length for `len`.
+type Sized interface {
+	Object
+	Len() int
+}
+
+// Iterable values can be looped over.
+type Iterable interface {
+	Object
+	Iter() Iterator
+}
+
+// Iterator gives the elements one at a time, reporting false once done.
+type Iterator interface {
+	Next() (Object, bool)
+}
+
+// Callable values can be called like functions.
+type Callable interface {
+	Object
+	Call(args ...Object) Object
+}
+
+// ResolveIndex counts negative indices back from the end and reports whether
+// the result is in bounds.
+func ResolveIndex(i int64, length int) (int64, bool) {
+	if i < 0 {
+		i += int64(length)
+	}
+	return i, i >= 0 && i < int64(length)
+}
+
+func newIndexError(format string, a ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func (ao *Array) Index(index Object) Object {
+	idx, ok := index.(*Int)
+	if !ok {
+		return newIndexError("index operator not supported: %s", ao.Type())
+	}
+	i, ok := ResolveIndex(idx.Value, len(ao.Elements))
+	if !ok {
+		return newIndexError("index out of bounds. len=%d, index=%d", len(ao.Elements), idx.Value)
+	}
+	return ao.Elements[i]
+}
+
+func (ao *Array) Len() int { return len(ao.Elements) }
+
+func (ao *Array) Iter() Iterator { return &arrayIterator{array: ao} }
+
+// arrayIterator reads the array as it goes, so elements pushed during a loop
+// are visited too.
+type arrayIterator struct {
+	array *Array
+	next  int
+}
+
+func (it *arrayIterator) Next() (Object, bool) {
+	if it.next >= len(it.array.Elements) {
+		return nil, false
+	}
+	it.next++
+	return it.array.Elements[it.next-1], true
+}
+
+func (s *String) Index(index Object) Object {
+	idx, ok := index.(*Int)
+	if !ok {
+		return newIndexError("index operator not supported: %s", s.Type())
+	}
+	runes := []rune(s.Value)
+	i, ok := ResolveIndex(idx.Value, len(runes))
+	if !ok {
+		return newIndexError("index out of bounds. len=%d, index=%d", len(runes), idx.Value)
+	}
+	return &String{Value: string(runes[i])}
+}
+
+func (s *String) Len() int { return utf8.RuneCountInString(s.Value) }
+
+// Iter gives the characters of the string.
+func (s *String) Iter() Iterator {
+	elements := []Object{}
+	for _, r := range s.Value {
+		elements = append(elements, &String{Value: string(r)})
+	}
+	return (&Array{Elements: elements}).Iter()
+}
+
+func (h *HashMap) Index(index Object) Object {
+	key, ok := AsHashable(index)
+	if !ok {
+		return newIndexError("Object not hashable. Type=%s", index.Type())
+	}
+	pair, ok := h.Get(key)
+	if !ok {
+		return nil
+	}
+	return pair.Value
+}
+
+// Iter gives the keys in insertion order, as they were when the loop started.
+func (h *HashMap) Iter() Iterator {
+	pairs := h.Pairs()
+	keys := make([]Object, len(pairs))
+	for i, pair := range pairs {
+		keys[i] = pair.Key
+	}
+	return (&Array{Elements: keys}).Iter()
+}
+
+func (ft *FlagType) Len() int { return len(ft.Members) }
+
+// Iter gives the members in declaration order.
+func (ft *FlagType) Iter() Iterator {
+	members := make([]Object, len(ft.Members))
+	for i, m := range ft.Members {
+		members[i] = m
+	}
+	return (&Array{Elements: members}).Iter()
+}
+
+func (b *Builtin) Call(args ...Object) Object { return b.Fn(args...) }
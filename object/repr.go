@@ -0,0 +1,135 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ChestFormat gives the text for a chest whose type declares its own, and
+// false to fall back to the default format.
+type ChestFormat func(chest *Chest) (string, bool)
+
+// Repr formats obj the way it would be written in source: strings are quoted
+// and escaped at every level. Containers that contain themselves print as
+// [...], {...} or |...| where the cycle closes.
+func Repr(obj Object) string {
+	return Format(obj, nil)
+}
+
+// Format is Repr with chests, at any level, given to format first. A chest
+// whose format is already running, e.g. one that formats itself, gets the
+// default format instead.
+func Format(obj Object, format ChestFormat) string {
+	var out strings.Builder
+	p := &printer{out: &out, format: format, printing: make(map[Object]bool)}
+	p.write(obj)
+	return out.String()
+}
+
+type printer struct {
+	out      *strings.Builder
+	format   ChestFormat
+	printing map[Object]bool
+}
+
+func (p *printer) write(obj Object) {
+	switch obj := obj.(type) {
+	case *String:
+		p.out.WriteString(Quote(obj.Value))
+	case *Array:
+		if p.printing[obj] {
+			p.out.WriteString("[...]")
+			return
+		}
+		p.printing[obj] = true
+		defer delete(p.printing, obj)
+		p.out.WriteString("[")
+		for i, e := range obj.Elements {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			p.write(e)
+		}
+		p.out.WriteString("]")
+	case *HashMap:
+		if p.printing[obj] {
+			p.out.WriteString("{...}")
+			return
+		}
+		p.printing[obj] = true
+		defer delete(p.printing, obj)
+		p.out.WriteString("{")
+		for i, pair := range obj.Pairs() {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			p.write(pair.Key)
+			p.out.WriteString(": ")
+			p.write(pair.Value)
+		}
+		p.out.WriteString("}")
+	case *Chest:
+		if p.printing[obj] {
+			p.out.WriteString("|...|")
+			return
+		}
+		p.printing[obj] = true
+		defer delete(p.printing, obj)
+		if p.format != nil && !obj.formatting {
+			obj.formatting = true
+			s, ok := p.format(obj)
+			obj.formatting = false
+			if ok {
+				p.out.WriteString(s)
+				return
+			}
+		}
+		if obj.ChestType != nil {
+			p.out.WriteString(obj.ChestType.Name)
+		}
+		p.out.WriteString("|")
+		for i, name := range obj.Fields() {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			p.out.WriteString(name)
+			p.out.WriteString(": ")
+			p.write(obj.Items[name])
+		}
+		p.out.WriteString("|")
+	default:
+		p.out.WriteString(obj.AsString())
+	}
+}
+
+// Quote wraps s in double quotes, escaping it so the lexer reads back the
+// same string.
+func Quote(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\r':
+			out.WriteString(`\r`)
+		case 0:
+			out.WriteString(`\0`)
+		default:
+			if unicode.IsPrint(r) {
+				out.WriteRune(r)
+			} else {
+				fmt.Fprintf(&out, `\u{%X}`, r)
+			}
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
@@ -8,6 +8,8 @@ import (
 	"pir-interpreter/lexer"
 	"pir-interpreter/token"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 type (
@@ -92,6 +94,8 @@ func (p *Parser) resolvePrefixParseFunc(tok token.TokenType) prefixParseFunc {
 		return p.parseFunctionLiteral
 	case token.STRING:
 		return p.parseStringLiteral
+	case token.FSTRING:
+		return p.parseInterpolatedString
 	case token.PIPE:
 		return p.parseChestLiteral
 	default:
@@ -139,7 +143,8 @@ func (p *Parser) resolveInfixParseFunc(tok token.TokenType) infixParseFunc {
 }
 
 func (p *Parser) Errors() []string {
-	return p.errors
+	errors := append([]string{}, p.l.Errors()...)
+	return append(errors, p.errors...)
 }
 
 func (p *Parser) createParserError(msg string, token token.Token) {
@@ -151,6 +156,11 @@ func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.YAR:
 		yarTok := p.curToken
+		if p.peekToken.Is(token.LBRACKET) || p.peekToken.Is(token.LBRACE) || p.peekToken.Is(token.PIPE) ||
+			p.peekToken.Is(token.IDENT) && p.peekToken2.Is(token.PIPE) {
+			p.advanceTokens()
+			return p.parseDestructuringYarStatement(yarTok)
+		}
 		if !p.expectPeekToken(token.IDENT) {
 			return nil
 		}
@@ -161,9 +171,20 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parsePortStatement()
 	case token.CHEST:
 		return p.parseChestStatement()
+	case token.FLAG:
+		return p.parseFlagStatement()
+	case token.MATCH:
+		if stmt := p.parseMatchStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
 	case token.IF:
 		return p.parseIfStatement()
 	case token.FOR:
+		// `in` is only special here, where two names in a row can't be a condition
+		if p.peekToken.Is(token.IDENT) && p.peekToken2.Is(token.IDENT) && p.peekToken2.Literal == "in" {
+			return p.parseForEachStatement()
+		}
 		return p.parseForStatement()
 	case token.BREAK:
 		return p.parseBreakStatement()
@@ -174,15 +195,23 @@ func (p *Parser) parseStatement() ast.Statement {
 	default:
 		startToken := p.curToken
 		expr := p.parseExpression(token.PREC_LOWEST)
-		if indexAssign, ok := expr.(*ast.IndexExpression); ok && p.peekToken.Is(token.BE) {
+		if indexAssign, ok := expr.(*ast.IndexExpression); ok && p.peekToken.IsAssignment() {
 			return p.parseIndexAssignment(startToken, indexAssign)
 		}
 
-		if chestAccess, ok := expr.(*ast.ChestAccess); ok && p.peekToken.Is(token.BE) {
+		if _, ok := expr.(*ast.SliceExpression); ok && p.peekToken.IsAssignment() {
+			p.createParserError("cannot assign to a slice", p.peekToken)
+			for p.curToken.IsNot(token.PERIOD) && p.curToken.IsNot(token.EOF) {
+				p.advanceTokens()
+			}
+			return nil
+		}
+
+		if chestAccess, ok := expr.(*ast.ChestAccess); ok && p.peekToken.IsAssignment() {
 			return p.parseChestFieldAssignment(chestAccess)
 		}
 
-		if _, ok := expr.(*ast.Identifier); ok && p.peekToken.Is(token.BE) {
+		if _, ok := expr.(*ast.Identifier); ok && p.peekToken.IsAssignment() {
 			return p.parseYarStatement(token.Token{Type: token.YAR, Literal: "FAKEYAR"})
 		}
 
@@ -194,6 +223,7 @@ func (p *Parser) parseStatement() ast.Statement {
 }
 
 func (p *Parser) parseIndexAssignment(startToken token.Token, indexAssign *ast.IndexExpression) *ast.IndexAssignment {
+	operator := p.peekToken.CompoundOperator()
 	p.advanceTokens()
 	p.advanceTokens()
 	value := p.parseExpression(token.PREC_LOWEST)
@@ -201,14 +231,16 @@ func (p *Parser) parseIndexAssignment(startToken token.Token, indexAssign *ast.I
 		p.advanceTokens()
 	}
 	return &ast.IndexAssignment{
-		Token: startToken,
-		Left:  indexAssign.Left,
-		Index: indexAssign.Index,
-		Value: value,
+		Token:    startToken,
+		Left:     indexAssign.Left,
+		Index:    indexAssign.Index,
+		Operator: operator,
+		Value:    value,
 	}
 }
 
 func (p *Parser) parseChestFieldAssignment(access *ast.ChestAccess) *ast.ChestFieldAssignment {
+	operator := p.peekToken.CompoundOperator()
 	p.advanceTokens() // current at 'be'
 	p.advanceTokens()
 	value := p.parseExpression(token.PREC_LOWEST)
@@ -223,10 +255,11 @@ func (p *Parser) parseChestFieldAssignment(access *ast.ChestAccess) *ast.ChestFi
 		p.advanceTokens()
 	}
 	return &ast.ChestFieldAssignment{
-		Token: access.Token,
-		Left:  access.Left,
-		Field: access.Field,
-		Value: value,
+		Token:    access.Token,
+		Left:     access.Left,
+		Field:    access.Field,
+		Operator: operator,
+		Value:    value,
 	}
 }
 
@@ -246,6 +279,120 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	is := &ast.InterpolatedString{Token: p.curToken}
+	raw := p.curToken.Literal
+	var text strings.Builder
+	flushText := func() bool {
+		if text.Len() == 0 {
+			return true
+		}
+		value, err := lexer.Unescape(text.String())
+		if err != nil {
+			p.createParserError(err.Error(), p.curToken)
+			return false
+		}
+		is.Parts = append(is.Parts, &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: value}, Value: value})
+		text.Reset()
+		return true
+	}
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case ch == '\\' && i+1 < len(raw):
+			text.WriteString(raw[i : i+2])
+			i++
+		case (ch == '{' || ch == '}') && i+1 < len(raw) && raw[i+1] == ch:
+			text.WriteByte(ch)
+			i++
+		case ch == '}':
+			p.createParserError("single '}' is not allowed in interpolated string", p.fstringToken(raw[:i]))
+			return nil
+		case ch == '{':
+			end := findInterpolationEnd(raw, i)
+			if end < 0 {
+				p.createParserError("unclosed '{' in interpolated string", p.fstringToken(raw[:i]))
+				return nil
+			}
+			if !flushText() {
+				return nil
+			}
+			expr := p.parseInterpolation(raw[i+1:end], raw[:i+1])
+			if expr == nil {
+				return nil
+			}
+			is.Parts = append(is.Parts, expr)
+			i = end
+		default:
+			text.WriteByte(ch)
+		}
+	}
+	if !flushText() {
+		return nil
+	}
+	return is
+}
+
+// fstringToken gives a token positioned at the character following before in
+// the body of the current f-string, counted the way the lexer counts. The
+// f-string token itself sits at the opening quote.
+func (p *Parser) fstringToken(before string) token.Token {
+	tok := token.Token{LineNum: p.curToken.LineNum, CharNum: p.curToken.CharNum + 1 + utf8.RuneCountInString(before)}
+	if newline := strings.LastIndexByte(before, '\n'); newline >= 0 {
+		tok.LineNum += strings.Count(before, "\n")
+		tok.CharNum = utf8.RuneCountInString(before[newline+1:]) + 2
+	}
+	return tok
+}
+
+// findInterpolationEnd returns the index of the '}' closing the hole opened at
+// start, or -1. Braces inside quoted strings are ignored.
+func findInterpolationEnd(raw string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseInterpolation parses the expression src of a hole, found right after
+// before in the body of the current f-string.
+func (p *Parser) parseInterpolation(src, before string) ast.Expression {
+	if strings.TrimSpace(src) == "" {
+		p.createParserError("empty expression in interpolated string", p.fstringToken(before))
+		return nil
+	}
+	start := p.fstringToken(before)
+	line, char := start.LineNum, start.CharNum-1
+	sub := New(lexer.NewAt(src, line, char))
+	expr := sub.parseExpression(token.PREC_LOWEST)
+	if sub.peekToken.IsNot(token.EOF) {
+		msg := fmt.Sprintf("unexpected %s in interpolated expression", sub.peekToken.Type)
+		sub.createParserError(msg, sub.peekToken)
+	}
+	if errors := sub.Errors(); len(errors) > 0 {
+		p.errors = append(p.errors, errors...)
+		return nil
+	}
+	return expr
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 	array.Elements = p.parseExpressionCollection(token.RBRACKET)
@@ -253,17 +400,24 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 }
 
 func (p *Parser) parseHashMapLiteral() ast.Expression {
-	hml := &ast.HashMapLiteral{Token: p.curToken}
-	hml.MP = make(map[ast.Expression]ast.Expression)
+	hml := &ast.HashMapLiteral{Token: p.curToken, Pairs: []*ast.HashMapPair{}}
+	seen := make(map[string]bool)
 	for p.peekToken.IsNot(token.RBRACE) {
 		p.advanceTokens()
-		key := p.parseExpression(token.PREC_LOWEST)
+		keyToken := p.curToken
+		key := p.parseHashMapKey()
 		if !p.expectPeekToken(token.COLOGNE) {
 			return nil
 		}
+		if literal, ok := literalKey(key); ok {
+			if seen[literal] {
+				p.createParserError(fmt.Sprintf("duplicate key in hashmap literal: %s", key.String()), keyToken)
+			}
+			seen[literal] = true
+		}
 		p.advanceTokens()
 		value := p.parseExpression(token.PREC_LOWEST)
-		hml.MP[key] = value
+		hml.Pairs = append(hml.Pairs, &ast.HashMapPair{Key: key, Value: value})
 		if p.peekToken.IsNot(token.RBRACE) && !p.expectPeekToken(token.COMMA) {
 			return nil
 		}
@@ -274,9 +428,39 @@ func (p *Parser) parseHashMapLiteral() ast.Expression {
 	return hml
 }
 
+// parseHashMapKey reads `{Color|Red: ...}` as keyed by the member Red. A
+// chest instantiated with named fields has to be in parentheses to be a key.
+func (p *Parser) parseHashMapKey() ast.Expression {
+	if p.curToken.Is(token.IDENT) && p.peekToken.Is(token.PIPE) &&
+		p.peekToken2.Is(token.IDENT) && p.peekToken3.Is(token.COLOGNE) {
+		left := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.advanceTokens()
+		pipeTok := p.curToken
+		p.advanceTokens()
+		field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		return &ast.ChestAccess{Token: pipeTok, Left: left, Field: field}
+	}
+	return p.parseExpression(token.PREC_LOWEST)
+}
+
+// literalKey identifies hashmap keys that are known at parse time, so that
+// duplicates can be reported before evaluation.
+func literalKey(key ast.Expression) (string, bool) {
+	switch key := key.(type) {
+	case *ast.StringLiteral:
+		return "STRING:" + key.Value, true
+	case *ast.IntegerLiteral:
+		return "INT:" + strconv.FormatInt(key.Value, 10), true
+	case *ast.Boolean:
+		return "BOOL:" + strconv.FormatBool(key.Value), true
+	default:
+		return "", false
+	}
+}
+
 func (p *Parser) parseChestLiteral() ast.Expression {
-	cl := &ast.ChestLiteral{Token: p.curToken}
-	cl.Items = make(map[*ast.Identifier]ast.Expression)
+	cl := &ast.ChestLiteral{Token: p.curToken, Items: []*ast.ChestArgument{}}
+	seen := make(map[string]bool)
 	for p.peekToken.IsNot(token.PIPE) {
 		if !p.expectPeekToken(token.IDENT) {
 			return nil
@@ -287,12 +471,16 @@ func (p *Parser) parseChestLiteral() ast.Expression {
 			p.advanceTokens()
 		}
 		key := &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: keyLiteral}, Value: keyLiteral}
+		if seen[keyLiteral] {
+			p.createParserError(fmt.Sprintf("duplicate field in chest literal: %s", keyLiteral), p.curToken)
+		}
+		seen[keyLiteral] = true
 		if !p.expectPeekToken(token.COLOGNE) {
 			return nil
 		}
 		p.advanceTokens()
 		value := p.parseExpression(token.PREC_LOWEST)
-		cl.Items[key] = value
+		cl.Items = append(cl.Items, &ast.ChestArgument{Name: key, Value: value})
 		if p.peekToken.IsNot(token.PIPE) && !p.expectPeekToken(token.COMMA) {
 			return nil
 		}
@@ -349,7 +537,9 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	funcLiteral.Params = p.parseFunctionParams()
+	if !p.parseFunctionParams(funcLiteral) {
+		return nil
+	}
 
 	if !p.expectPeekToken(token.COLOGNE) {
 		return nil
@@ -357,29 +547,65 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	p.advanceTokens()
 
 	funcLiteral.Body = p.parseBlockStatement()
+	if len(funcLiteral.Body.Statements) > 1 {
+		if doc, ok := funcLiteral.Body.Statements[0].(*ast.ExpressionStatement); ok {
+			if str, ok := doc.Expression.(*ast.StringLiteral); ok {
+				funcLiteral.Doc = str.Value
+			}
+		}
+	}
 	return funcLiteral
 }
 
-func (p *Parser) parseFunctionParams() []*ast.Identifier {
-	params := []*ast.Identifier{}
-	if p.peekToken.Is(token.RPAREN) {
-		p.advanceTokens()
-		return params
+// parseFunctionParams parses `(a, b be 1, ..rest)` into fn. Parameters with a
+// default follow the ones without, and the rest parameter comes last.
+func (p *Parser) parseFunctionParams(fn *ast.FunctionLiteral) bool {
+	fn.Params = []*ast.Identifier{}
+	seen := make(map[string]bool)
+	for p.peekToken.IsNot(token.RPAREN) {
+		if fn.Rest != nil {
+			p.createParserError("the rest parameter must come last", p.peekToken)
+			return false
+		}
+		if p.peekToken.Is(token.PERIOD) {
+			p.advanceTokens()
+			if !p.expectPeekToken(token.PERIOD) || !p.expectPeekToken(token.IDENT) {
+				return false
+			}
+			fn.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			p.checkDuplicateParam(fn.Rest, seen)
+		} else {
+			if !p.expectPeekToken(token.IDENT) {
+				return false
+			}
+			param := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			p.checkDuplicateParam(param, seen)
+			if p.peekToken.Is(token.BE) {
+				p.advanceTokens()
+				p.advanceTokens()
+				if fn.Defaults == nil {
+					fn.Defaults = make(map[string]ast.Expression)
+				}
+				fn.Defaults[param.Value] = p.parseExpression(token.PREC_LOWEST)
+			} else if len(fn.Defaults) > 0 {
+				p.createParserError(fmt.Sprintf("parameter %s without a default follows one with a default", param.Value), p.curToken)
+			}
+			fn.Params = append(fn.Params, param)
+		}
+		if p.peekToken.IsNot(token.RPAREN) && !p.expectPeekToken(token.COMMA) {
+			return false
+		}
 	}
 	p.advanceTokens()
+	return true
+}
 
-	params = append(params, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
-	for p.peekToken.Is(token.COMMA) {
-		p.advanceTokens()
-		p.advanceTokens()
-		params = append(params, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
-	}
-
-	if !p.expectPeekToken(token.RPAREN) {
-		return nil
+// checkDuplicateParam records param in seen, reporting it if it was already there.
+func (p *Parser) checkDuplicateParam(param *ast.Identifier, seen map[string]bool) {
+	if seen[param.Value] {
+		p.createParserError(fmt.Sprintf("duplicate parameter: %s", param.Value), param.Token)
 	}
-
-	return params
+	seen[param.Value] = true
 }
 
 func (p *Parser) parseChestItemNames() []*ast.Identifier {
@@ -404,29 +630,75 @@ func (p *Parser) parseChestItemNames() []*ast.Identifier {
 	return params
 }
 
+// parseCallExpression parses the arguments of a call, positional ones first
+// and then named ones like `name: value`.
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
-	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionCollection(token.RPAREN)
+	exp := &ast.CallExpression{Token: p.curToken, Function: function, Arguments: []ast.Expression{}}
+	seen := make(map[string]bool)
+	for p.peekToken.IsNot(token.RPAREN) {
+		p.advanceTokens()
+		if p.curToken.Is(token.IDENT) && p.peekToken.Is(token.COLOGNE) {
+			name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if seen[name.Value] {
+				p.createParserError(fmt.Sprintf("duplicate argument: %s", name.Value), p.curToken)
+			}
+			seen[name.Value] = true
+			p.advanceTokens()
+			p.advanceTokens()
+			exp.NamedArguments = append(exp.NamedArguments, &ast.NamedArgument{Name: name, Value: p.parseExpression(token.PREC_LOWEST)})
+		} else {
+			if len(exp.NamedArguments) > 0 {
+				p.createParserError("positional argument after a named argument", p.curToken)
+			}
+			exp.Arguments = append(exp.Arguments, p.parseExpression(token.PREC_LOWEST))
+		}
+		if p.peekToken.IsNot(token.RPAREN) && !p.expectPeekToken(token.COMMA) {
+			return nil
+		}
+	}
+	p.advanceTokens()
 	return exp
 }
 
 func (p *Parser) parseIndexExpression(collection ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: collection}
 	p.advanceTokens()
+	if p.curToken.Is(token.COLOGNE) {
+		return p.parseSliceExpression(exp.Token, collection, nil)
+	}
 	exp.Index = p.parseExpression(token.PREC_LOWEST)
+	if p.peekToken.Is(token.COLOGNE) {
+		p.advanceTokens()
+		return p.parseSliceExpression(exp.Token, collection, exp.Index)
+	}
 	if !p.expectPeekToken(token.RBRACKET) {
 		return nil
 	}
 	return exp
 }
 
+// parseSliceExpression expects the current token to be the ':' of the slice.
+func (p *Parser) parseSliceExpression(tok token.Token, collection, start ast.Expression) ast.Expression {
+	slice := &ast.SliceExpression{Token: tok, Left: collection, Start: start}
+	if p.peekToken.IsNot(token.RBRACKET) {
+		p.advanceTokens()
+		slice.End = p.parseExpression(token.PREC_LOWEST)
+	}
+	if !p.expectPeekToken(token.RBRACKET) {
+		return nil
+	}
+	return slice
+}
+
 func (p *Parser) parseChestAccessOrInstantiation(left ast.Expression) ast.Expression {
 	pipeTok := p.curToken
 	// Determine if this is a chest access
 	if p.peekToken.Is(token.IDENT) {
 		t2 := p.peekToken2.Type
 		t3 := p.peekToken3.Type
-		if isChestAccessTerminator(t2) || t2 == token.LPAREN || t2 == token.LBRACKET || (p.peekToken2.Is(token.PIPE) && t3 == token.IDENT) {
+		// `match p|x: when` ends the subject at the ':', no chest field is named when
+		if isChestAccessTerminator(t2) || t2 == token.LPAREN || t2 == token.LBRACKET || (p.peekToken2.Is(token.PIPE) && t3 == token.IDENT) ||
+			(t2 == token.COLOGNE && t3 == token.WHEN) {
 			p.advanceTokens()
 			fieldTok := p.curToken
 			ident := &ast.Identifier{Token: fieldTok, Value: fieldTok.Literal}
@@ -439,6 +711,7 @@ func (p *Parser) parseChestAccessOrInstantiation(left ast.Expression) ast.Expres
 	// Determine if named arguments are used
 	if p.peekToken.Is(token.IDENT) && p.peekToken2.Is(token.COLOGNE) {
 		inst.NamedArgs = []*ast.ChestArgument{}
+		seen := make(map[string]bool)
 		for p.peekToken.IsNot(token.PIPE) {
 			p.advanceTokens() // current at identifier
 			keyLiteral := p.curToken.Literal
@@ -447,6 +720,10 @@ func (p *Parser) parseChestAccessOrInstantiation(left ast.Expression) ast.Expres
 				p.advanceTokens()
 			}
 			name := &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: keyLiteral}, Value: keyLiteral}
+			if seen[keyLiteral] {
+				p.createParserError(fmt.Sprintf("duplicate field: %s", keyLiteral), p.curToken)
+			}
+			seen[keyLiteral] = true
 			if !p.expectPeekToken(token.COLOGNE) {
 				return nil
 			}
@@ -476,7 +753,8 @@ func (p *Parser) parseChestAccessOrInstantiation(left ast.Expression) ast.Expres
 
 func isChestAccessTerminator(t token.TokenType) bool {
 	switch t {
-	case token.PERIOD, token.BE, token.PLUS, token.MINUS, token.FSLASH,
+	case token.PERIOD, token.BE, token.PLUSBE, token.MINUSBE, token.STARBE, token.FSLASHBE,
+		token.PLUS, token.MINUS, token.FSLASH,
 		token.STAR, token.MOD, token.EQUAL, token.NOTEQUAL, token.AND,
 		token.OR, token.LESS, token.GREATER, token.LESSEQ, token.GREATEREQ,
 		token.RPAREN, token.RBRACKET, token.RBRACE, token.COMMA,
@@ -489,7 +767,7 @@ func isChestAccessTerminator(t token.TokenType) bool {
 
 func isExpressionStart(t token.TokenType) bool {
 	switch t {
-	case token.IDENT, token.INT, token.FOR, token.STRING, token.LPAREN, token.LBRACKET,
+	case token.IDENT, token.INT, token.FOR, token.STRING, token.FSTRING, token.LPAREN, token.LBRACKET,
 		token.LBRACE, token.TRUE, token.FALSE, token.AAAA, token.MINUS, token.F, token.PIPE:
 		return true
 	default:
@@ -530,6 +808,25 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 	return stmt
 }
 
+func (p *Parser) parseForEachStatement() *ast.ForEachStatement {
+	stmt := &ast.ForEachStatement{Token: p.curToken}
+	p.advanceTokens()
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.advanceTokens()
+	p.advanceTokens()
+	stmt.Iterable = p.parseExpression(token.PREC_LOWEST)
+
+	if !p.expectPeekToken(token.COLOGNE) {
+		return nil
+	}
+
+	p.advanceTokens()
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
 func (p *Parser) parseIfStatement() *ast.IfStatement {
 	statement := &ast.IfStatement{Token: p.curToken}
 
@@ -567,12 +864,38 @@ func (p *Parser) parseYarStatement(start token.Token) *ast.YarStatement {
 
 	statement.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	if !p.expectPeekToken(token.BE) {
+	// Only reassignments, which have no real yar token, take compound forms.
+	if operator := p.peekToken.CompoundOperator(); operator != "" && start.Literal == "FAKEYAR" {
+		statement.Operator = operator
+		p.advanceTokens()
+	} else if !p.expectPeekToken(token.BE) {
 		return nil
 	}
 
 	p.advanceTokens()
 
+	statement.Value = p.parseExpression(token.PREC_LOWEST)
+	if fn, ok := statement.Value.(*ast.FunctionLiteral); ok && statement.Operator == "" {
+		fn.Name = statement.Name.Value
+	}
+
+	if p.peekToken.Is(token.PERIOD) {
+		p.advanceTokens()
+	}
+
+	return statement
+}
+
+// parseDestructuringYarStatement parses `yar pattern be value`, with the
+// current token at the start of the array, hash map or chest pattern.
+func (p *Parser) parseDestructuringYarStatement(start token.Token) *ast.YarStatement {
+	statement := &ast.YarStatement{Token: start}
+	statement.Pattern = p.parsePattern(make(map[string]bool))
+	if statement.Pattern == nil || !p.expectPeekToken(token.BE) {
+		return nil
+	}
+	p.advanceTokens()
+
 	statement.Value = p.parseExpression(token.PREC_LOWEST)
 
 	if p.peekToken.Is(token.PERIOD) {
@@ -617,13 +940,65 @@ func (p *Parser) parseChestStatement() *ast.ChestStatement {
 		return nil
 	}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekToken.Is(token.LPAREN) {
+		p.advanceTokens()
+		if !p.expectPeekToken(token.IDENT) {
+			return nil
+		}
+		stmt.Parent = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.expectPeekToken(token.RPAREN) {
+			return nil
+		}
+	}
 	if !p.expectPeekToken(token.PIPE) {
 		return nil
 	}
-	stmt.FieldList = []*ast.Identifier{}
+	stmt.FieldList = []*ast.ChestField{}
 	for p.peekToken.IsNot(token.PIPE) {
+		field := p.parseChestField()
+		if field == nil {
+			return nil
+		}
+		stmt.FieldList = append(stmt.FieldList, field)
+		if p.peekToken.IsNot(token.PIPE) && !p.expectPeekToken(token.COMMA) {
+			return nil
+		}
+	}
+	if !p.expectPeekToken(token.PIPE) {
+		return nil
+	}
+	if p.peekToken.Is(token.COLOGNE) {
 		p.advanceTokens()
-		stmt.FieldList = append(stmt.FieldList, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		p.advanceTokens()
+		stmt.Methods = p.parseChestMethods()
+		return stmt
+	}
+	if p.peekToken.Is(token.PERIOD) {
+		p.advanceTokens()
+	}
+	return stmt
+}
+
+func (p *Parser) parseFlagStatement() *ast.FlagStatement {
+	stmt := &ast.FlagStatement{Token: p.curToken}
+	if !p.expectPeekToken(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeekToken(token.PIPE) {
+		return nil
+	}
+	stmt.Members = []*ast.Identifier{}
+	seen := make(map[string]bool)
+	for p.peekToken.IsNot(token.PIPE) {
+		if !p.expectPeekToken(token.IDENT) {
+			return nil
+		}
+		if seen[p.curToken.Literal] {
+			p.createParserError(fmt.Sprintf("duplicate member in flag %s: %s", stmt.Name.Value, p.curToken.Literal), p.curToken)
+		}
+		seen[p.curToken.Literal] = true
+		stmt.Members = append(stmt.Members, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
 		if p.peekToken.IsNot(token.PIPE) && !p.expectPeekToken(token.COMMA) {
 			return nil
 		}
@@ -631,12 +1006,304 @@ func (p *Parser) parseChestStatement() *ast.ChestStatement {
 	if !p.expectPeekToken(token.PIPE) {
 		return nil
 	}
+	if len(stmt.Members) == 0 {
+		p.createParserError(fmt.Sprintf("flag %s needs at least one member", stmt.Name.Value), p.curToken)
+	}
 	if p.peekToken.Is(token.PERIOD) {
 		p.advanceTokens()
 	}
 	return stmt
 }
 
+// parseChestField parses `name`, `name?` or `name be default`, each optionally
+// followed by `if validator`.
+func (p *Parser) parseChestField() *ast.ChestField {
+	if !p.expectPeekToken(token.IDENT) {
+		return nil
+	}
+	field := &ast.ChestField{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+	if p.peekToken.Is(token.QUESTION) {
+		p.advanceTokens()
+		field.Optional = true
+	} else if p.peekToken.Is(token.BE) {
+		p.advanceTokens()
+		p.advanceTokens()
+		field.Default = p.parseExpression(token.PREC_LOWEST)
+	}
+	if p.peekToken.Is(token.IF) {
+		p.advanceTokens()
+		p.advanceTokens()
+		field.Validator = p.parseExpression(token.PREC_LOWEST)
+	}
+	return field
+}
+
+// parseChestMethods parses the block of a chest statement, which may only
+// hold `name be f(...): ...` definitions. A method ends with the period
+// closing its function body, so the next period closes the chest block.
+func (p *Parser) parseChestMethods() []*ast.ChestMethod {
+	methods := []*ast.ChestMethod{}
+	seen := make(map[string]bool)
+	for !p.curToken.IsBlockTerminator() {
+		if p.curToken.IsNot(token.IDENT) || p.peekToken.IsNot(token.BE) || p.peekToken2.IsNot(token.F) {
+			p.createParserError("only methods can be declared in a chest block", p.curToken)
+			return nil
+		}
+		name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if seen[name.Value] {
+			p.createParserError(fmt.Sprintf("duplicate method: %s", name.Value), p.curToken)
+		}
+		seen[name.Value] = true
+		p.advanceTokens()
+		p.advanceTokens()
+		fn, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+		if !ok || fn == nil {
+			return nil
+		}
+		fn.Name = name.Value
+		methods = append(methods, &ast.ChestMethod{Name: name, Function: fn})
+		p.advanceTokens()
+	}
+	return methods
+}
+
+func (p *Parser) parseMatchStatement() *ast.MatchStatement {
+	stmt := &ast.MatchStatement{Token: p.curToken}
+	p.advanceTokens()
+	stmt.Subject = p.parseExpression(token.PREC_LOWEST)
+	if !p.expectPeekToken(token.COLOGNE) {
+		return nil
+	}
+	p.advanceTokens()
+
+	for p.curToken.Is(token.WHEN) {
+		arm := &ast.MatchArm{Token: p.curToken}
+		p.advanceTokens()
+		arm.Pattern = p.parsePattern(make(map[string]bool))
+		if arm.Pattern == nil {
+			return nil
+		}
+		if p.peekToken.Is(token.IF) {
+			p.advanceTokens()
+			p.advanceTokens()
+			arm.Guard = p.parseExpression(token.PREC_LOWEST)
+		}
+		if !p.expectPeekToken(token.COLOGNE) {
+			return nil
+		}
+		p.advanceTokens()
+		arm.Body = p.parseBlockStatement()
+		stmt.Arms = append(stmt.Arms, arm)
+	}
+
+	if p.curToken.Is(token.LS) {
+		if !p.expectPeekToken(token.COLOGNE) {
+			return nil
+		}
+		p.advanceTokens()
+		stmt.Alternate = p.parseBlockStatement()
+	}
+	if p.curToken.IsNot(token.PERIOD) {
+		p.createParserError(fmt.Sprintf("expected when, ls or . in match, got %s", p.curToken.Type), p.curToken)
+		return nil
+	}
+	if len(stmt.Arms) == 0 {
+		p.createParserError("match needs at least one when", stmt.Token)
+		return nil
+	}
+	return stmt
+}
+
+// parsePattern parses the pattern starting at the current token. Every name
+// a pattern binds is recorded in bound, so it can't be bound twice.
+func (p *Parser) parsePattern(bound map[string]bool) ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.peekToken.Is(token.PIPE) {
+			return p.parseChestPattern(bound)
+		}
+		return p.parseNamePattern(bound)
+	case token.PIPE:
+		return p.parseChestPattern(bound)
+	case token.LBRACKET:
+		return p.parseArrayPattern(bound)
+	case token.LBRACE:
+		return p.parseHashMapPattern(bound)
+	case token.LPAREN:
+		pattern := &ast.ValuePattern{Token: p.curToken}
+		pattern.Value = p.parseGroupedExpression()
+		if pattern.Value == nil {
+			return nil
+		}
+		return pattern
+	default:
+		value := p.parseLiteralPatternValue()
+		if value == nil {
+			return nil
+		}
+		return &ast.LiteralPattern{Value: value}
+	}
+}
+
+// parseLiteralPatternValue parses an int, string or bool literal, the only
+// expressions allowed in a pattern without parentheses.
+func (p *Parser) parseLiteralPatternValue() ast.Expression {
+	switch p.curToken.Type {
+	case token.INT, token.FOR:
+		return p.parseIntegerLiteral()
+	case token.MINUS:
+		if p.peekToken.IsNot(token.INT) && p.peekToken.IsNot(token.FOR) {
+			break
+		}
+		minus := p.curToken
+		p.advanceTokens()
+		return &ast.PrefixExpression{Token: minus, Operator: "-", Right: p.parseIntegerLiteral()}
+	case token.STRING:
+		return p.parseStringLiteral()
+	case token.TRUE, token.FALSE:
+		return p.parseBoolean()
+	}
+	p.createParserError(fmt.Sprintf("invalid pattern: %s", p.curToken.Literal), p.curToken)
+	return nil
+}
+
+// parseNamePattern parses `_` or a name to bind.
+func (p *Parser) parseNamePattern(bound map[string]bool) ast.Pattern {
+	if p.curToken.Literal == "_" {
+		return &ast.WildcardPattern{Token: p.curToken}
+	}
+	return &ast.BindingPattern{Name: p.bindPatternName(bound)}
+}
+
+func (p *Parser) bindPatternName(bound map[string]bool) *ast.Identifier {
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if name.Value == "_" {
+		return name
+	}
+	if bound[name.Value] {
+		p.createParserError(fmt.Sprintf("%s is bound twice in the same pattern", name.Value), p.curToken)
+	}
+	bound[name.Value] = true
+	return name
+}
+
+// parseRestPattern parses `..name` starting at the first period.
+func (p *Parser) parseRestPattern(bound map[string]bool) *ast.RestPattern {
+	rest := &ast.RestPattern{Token: p.curToken}
+	if !p.expectPeekToken(token.PERIOD) || !p.expectPeekToken(token.IDENT) {
+		return nil
+	}
+	rest.Name = p.bindPatternName(bound)
+	return rest
+}
+
+func (p *Parser) parseArrayPattern(bound map[string]bool) ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+	hasRest := false
+	for p.peekToken.IsNot(token.RBRACKET) {
+		p.advanceTokens()
+		var el ast.Pattern
+		if p.curToken.Is(token.PERIOD) {
+			if hasRest {
+				p.createParserError("a pattern can only have one rest element", p.curToken)
+			}
+			hasRest = true
+			if rest := p.parseRestPattern(bound); rest != nil {
+				el = rest
+			}
+		} else {
+			el = p.parsePattern(bound)
+		}
+		if el == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, el)
+		if p.peekToken.IsNot(token.RBRACKET) && !p.expectPeekToken(token.COMMA) {
+			return nil
+		}
+	}
+	p.advanceTokens()
+	return pattern
+}
+
+func (p *Parser) parseHashMapPattern(bound map[string]bool) ast.Pattern {
+	pattern := &ast.HashMapPattern{Token: p.curToken, Pairs: []*ast.HashMapPatternPair{}}
+	for p.peekToken.IsNot(token.RBRACE) {
+		p.advanceTokens()
+		if p.curToken.Is(token.PERIOD) {
+			if pattern.Rest != nil {
+				p.createParserError("a pattern can only have one rest element", p.curToken)
+			}
+			pattern.Rest = p.parseRestPattern(bound)
+			if pattern.Rest == nil {
+				return nil
+			}
+		} else {
+			var key ast.Expression
+			if p.curToken.Is(token.LPAREN) {
+				key = p.parseGroupedExpression()
+			} else {
+				key = p.parseLiteralPatternValue()
+			}
+			if key == nil || !p.expectPeekToken(token.COLOGNE) {
+				return nil
+			}
+			p.advanceTokens()
+			value := p.parsePattern(bound)
+			if value == nil {
+				return nil
+			}
+			pattern.Pairs = append(pattern.Pairs, &ast.HashMapPatternPair{Key: key, Value: value})
+		}
+		if p.peekToken.IsNot(token.RBRACE) && !p.expectPeekToken(token.COMMA) {
+			return nil
+		}
+	}
+	p.advanceTokens()
+	return pattern
+}
+
+func (p *Parser) parseChestPattern(bound map[string]bool) ast.Pattern {
+	pattern := &ast.ChestPattern{Token: p.curToken}
+	if p.curToken.Is(token.IDENT) {
+		pattern.Type = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.advanceTokens()
+	}
+	seen := make(map[string]bool)
+	for p.peekToken.IsNot(token.PIPE) {
+		if !p.expectPeekToken(token.IDENT) {
+			return nil
+		}
+		field := &ast.ChestFieldPattern{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		if seen[field.Name.Value] {
+			p.createParserError(fmt.Sprintf("duplicate field in pattern: %s", field.Name.Value), p.curToken)
+		}
+		seen[field.Name.Value] = true
+		if p.peekToken.Is(token.COLOGNE) {
+			p.advanceTokens()
+			p.advanceTokens()
+			if p.curToken.Is(token.IDENT) {
+				// A name after `field:` always binds, so in `y: name|` the pipe
+				// closes this pattern. Nested chest patterns are untyped: `a: |b|`.
+				field.Pattern = p.parseNamePattern(bound)
+			} else {
+				field.Pattern = p.parsePattern(bound)
+			}
+			if field.Pattern == nil {
+				return nil
+			}
+		} else {
+			p.bindPatternName(bound)
+		}
+		pattern.Fields = append(pattern.Fields, field)
+		if p.peekToken.IsNot(token.PIPE) && !p.expectPeekToken(token.COMMA) {
+			return nil
+		}
+	}
+	p.advanceTokens()
+	return pattern
+}
+
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefixFunc := p.resolvePrefixParseFunc(p.curToken.Type)
 	if prefixFunc == nil {
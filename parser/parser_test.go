@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"pir-interpreter/ast"
 	"pir-interpreter/lexer"
+	"strings"
 	"testing"
 )
 
@@ -920,21 +921,27 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 	if !ok {
 		t.Fatalf("exp is not ast.HashMapLiteral. got=%T", stmt.Expression)
 	}
-	if len(hash.MP) != 3 {
-		t.Errorf("hash.MP has wrong length. got=%d", len(hash.MP))
+	if len(hash.Pairs) != 3 {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
-	expected := map[string]int64{
-		"one":   1,
-		"two":   2,
-		"three": 3,
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
 	}
-	for key, value := range hash.MP {
-		literal, ok := key.(*ast.StringLiteral)
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
 		if !ok {
-			t.Errorf("key is not ast.StringLiteral. got=%T", key)
+			t.Errorf("key is not ast.StringLiteral. got=%T", pair.Key)
+			continue
+		}
+		if literal.String() != expected[i].key {
+			t.Errorf("pair %d has wrong key. expected=%q, got=%q", i, expected[i].key, literal.String())
 		}
-		expectedValue := expected[literal.String()]
-		testIntegerLiteral(t, value, expectedValue)
+		testIntegerLiteral(t, pair.Value, expected[i].value)
 	}
 }
 
@@ -947,8 +954,8 @@ func TestParsingEmptyHashMapLiteral(t *testing.T) {
 	if !ok {
 		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
 	}
-	if len(hash.MP) != 0 {
-		t.Errorf("hash.MP has wrong length. got=%d", len(hash.MP))
+	if len(hash.Pairs) != 0 {
+		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 }
 
@@ -961,8 +968,8 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 	if !ok {
 		t.Fatalf("exp is not ast.HashMapLiteral. got=%T", stmt.Expression)
 	}
-	if len(hash.MP) != 3 {
-		t.Errorf("hash.MP has wrong length. got=%d", len(hash.MP))
+	if len(hash.Pairs) != 3 {
+		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 	tests := map[string]func(ast.Expression){
 		"one": func(e ast.Expression) {
@@ -975,10 +982,10 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 			testInfixExpression(t, e, 15, "/", 5)
 		},
 	}
-	for key, value := range hash.MP {
-		literal, ok := key.(*ast.StringLiteral)
+	for _, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
 		if !ok {
-			t.Errorf("key is not ast.StringLiteral. got=%T", key)
+			t.Errorf("key is not ast.StringLiteral. got=%T", pair.Key)
 			continue
 		}
 		testFunc, ok := tests[literal.String()]
@@ -986,7 +993,31 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 			t.Errorf("No test function for key %q found", literal.String())
 			continue
 		}
-		testFunc(value)
+		testFunc(pair.Value)
+	}
+}
+
+func TestParsingHashLiteralsMemberKeys(t *testing.T) {
+	input := `{Color|Red: "stop", (P|x: 1|): "go"}`
+	program, p := parseProgramFromInput(input)
+	printErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashMapLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashMapLiteral. got=%T", stmt.Expression)
+	}
+	if len(hash.Pairs) != 2 {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+	access, ok := hash.Pairs[0].Key.(*ast.ChestAccess)
+	if !ok {
+		t.Fatalf("key is not ast.ChestAccess. got=%T", hash.Pairs[0].Key)
+	}
+	if access.String() != "Color|Red" {
+		t.Errorf("key has wrong value. got=%q", access.String())
+	}
+	if _, ok := hash.Pairs[1].Key.(*ast.ChestInstantiation); !ok {
+		t.Errorf("key is not ast.ChestInstantiation. got=%T", hash.Pairs[1].Key)
 	}
 }
 
@@ -1025,6 +1056,37 @@ func TestForStatementParsing(t *testing.T) {
 	}
 }
 
+func TestForEachStatementParsing(t *testing.T) {
+	input := "4 x in [1, 2]: ahoy(x).."
+	program, p := parseProgramFromInput(input)
+	printErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ForEachStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForEachStatement. got=%T", program.Statements[0])
+	}
+	if !testIdentifier(t, stmt.Variable, "x") {
+		return
+	}
+	expected := "4 x in [1, 2]: (ahoy(x).)"
+	if stmt.String() != expected {
+		t.Errorf("ForEachStatement.String() mismatch. Expected=%q, Got=%q", expected, stmt.String())
+	}
+
+	// in is only a keyword right after the loop variable
+	program, p = parseProgramFromInput("yar in be [1]. 4 in in in: ahoy(in)..")
+	printErrors(t, p)
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+	if _, ok := program.Statements[1].(*ast.ForEachStatement); !ok {
+		t.Fatalf("program.Statements[1] is not ast.ForEachStatement. got=%T", program.Statements[1])
+	}
+}
+
 func TestParsingChestLiteralWithExpressions(t *testing.T) {
 	input := `| field1: 2 * 3, field2: 1 + 2 |`
 	program, p := parseProgramFromInput(input)
@@ -1045,13 +1107,52 @@ func TestParsingChestLiteralWithExpressions(t *testing.T) {
 			testInfixExpression(t, e, 1, "+", 2)
 		},
 	}
-	for id, value := range ChestLiteral.Items {
-		testFunc, ok := tests[id.Value]
+	for _, item := range ChestLiteral.Items {
+		testFunc, ok := tests[item.Name.Value]
 		if !ok {
-			t.Errorf("No test function for key %s found", id)
+			t.Errorf("No test function for key %s found", item.Name)
 			continue
 		}
-		testFunc(value)
+		testFunc(item.Value)
+	}
+}
+
+func TestLiteralsKeepSourceOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, `{z:1, a:2, m:3}`},
+		{`|z: 1, a: 2, m: 3|`, `|z:1, a:2, m:3|`},
+	}
+	for _, tt := range tests {
+		program, p := parseProgramFromInput(tt.input)
+		printErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("wrong order. expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestDuplicateLiteralKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a": 1, "b": 2, "a": 3}.`, `duplicate key in hashmap literal: a`},
+		{`{1: 1, 1: 2}.`, `duplicate key in hashmap literal: 1`},
+		{`|a: 1, a: 2|.`, `duplicate field in chest literal: a`},
+	}
+	for _, tt := range tests {
+		_, p := parseProgramFromInput(tt.input)
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser error for %q", tt.input)
+			continue
+		}
+		if !strings.HasPrefix(errors[0], tt.expected) {
+			t.Errorf("wrong error for %q. expected prefix=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
 	}
 }
 
@@ -1097,10 +1198,10 @@ func TestChestStatementParsing(t *testing.T) {
 	if len(stmt.FieldList) != 2 {
 		t.Fatalf("ChestStatement.FieldList length expected 2, got=%d", len(stmt.FieldList))
 	}
-	if !testIdentifier(t, stmt.FieldList[0], "foo") {
+	if !testIdentifier(t, stmt.FieldList[0].Name, "foo") {
 		return
 	}
-	if !testIdentifier(t, stmt.FieldList[1], "bar") {
+	if !testIdentifier(t, stmt.FieldList[1].Name, "bar") {
 		return
 	}
 
@@ -1110,6 +1211,355 @@ func TestChestStatementParsing(t *testing.T) {
 	}
 }
 
+func TestChestStatementWithMethods(t *testing.T) {
+	input := `
+chest Point|x, y|:
+  norm be f():
+    gives self|x * self|x + self|y * self|y.
+  .
+  scale be f(by): gives [self|x * by, self|y * by]..
+.
+yar p be Point|1, 2|.`
+	program, p := parseProgramFromInput(input)
+	printErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ChestStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ChestStatement, got=%T", program.Statements[0])
+	}
+	if len(stmt.Methods) != 2 {
+		t.Fatalf("ChestStatement.Methods length expected 2, got=%d", len(stmt.Methods))
+	}
+	for i, name := range []string{"norm", "scale"} {
+		if stmt.Methods[i].Name.Value != name {
+			t.Errorf("method %d has wrong name. expected=%q, got=%q", i, name, stmt.Methods[i].Name.Value)
+		}
+	}
+	if len(stmt.Methods[1].Function.Params) != 1 {
+		t.Errorf("scale should take 1 param, got=%d", len(stmt.Methods[1].Function.Params))
+	}
+}
+
+func TestChestFieldOptions(t *testing.T) {
+	input := `chest Ship|name, crew be 10, parrot?, gold be 0 if f(g): g >= 0..|.`
+	program, p := parseProgramFromInput(input)
+	printErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ChestStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ChestStatement, got=%T", program.Statements[0])
+	}
+	if len(stmt.FieldList) != 4 {
+		t.Fatalf("ChestStatement.FieldList length expected 4, got=%d", len(stmt.FieldList))
+	}
+	name, crew, parrot, gold := stmt.FieldList[0], stmt.FieldList[1], stmt.FieldList[2], stmt.FieldList[3]
+	if name.Optional || name.Default != nil || name.Validator != nil {
+		t.Errorf("name should be a plain field, got %s", name)
+	}
+	testIntegerLiteral(t, crew.Default, 10)
+	if !parrot.Optional || parrot.Default != nil {
+		t.Errorf("parrot should be optional, got %s", parrot)
+	}
+	testIntegerLiteral(t, gold.Default, 0)
+	if _, ok := gold.Validator.(*ast.FunctionLiteral); !ok {
+		t.Errorf("gold validator is not *ast.FunctionLiteral, got=%T", gold.Validator)
+	}
+
+	expected := "chest Ship|name, crew be 10, parrot?, gold be 0 if f(g) ((g >= 0).)|."
+	if stmt.String() != expected {
+		t.Errorf("ChestStatement.String() mismatch. expected=%q, got=%q", expected, stmt.String())
+	}
+}
+
+func TestChestStatementWithParent(t *testing.T) {
+	input := "chest Captain(Pirate)|ship|."
+	program, p := parseProgramFromInput(input)
+	printErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ChestStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ChestStatement, got=%T", program.Statements[0])
+	}
+	if !testIdentifier(t, stmt.Parent, "Pirate") {
+		return
+	}
+	if stmt.String() != input {
+		t.Errorf("ChestStatement.String() mismatch. expected=%q, got=%q", input, stmt.String())
+	}
+}
+
+func TestChestMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"chest P|x|: y be 1. .", "only methods can be declared in a chest block"},
+		{"chest P|x|: ahoy(1). .", "only methods can be declared in a chest block"},
+		{"chest P|x|: a be f(): 1.. a be f(): 2.. .", "duplicate method: a"},
+	}
+	for _, tt := range tests {
+		_, p := parseProgramFromInput(tt.input)
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser error for %q", tt.input)
+			continue
+		}
+		if !strings.HasPrefix(errors[0], tt.expected) {
+			t.Errorf("wrong error for %q. expected prefix=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestFlagStatement(t *testing.T) {
+	input := "flag Color|Red, Green, Blue|."
+	program, p := parseProgramFromInput(input)
+	printErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.FlagStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.FlagStatement, got=%T", program.Statements[0])
+	}
+	if !testIdentifier(t, stmt.Name, "Color") {
+		return
+	}
+	if len(stmt.Members) != 3 {
+		t.Fatalf("wrong number of members. expected=3, got=%d", len(stmt.Members))
+	}
+	for i, name := range []string{"Red", "Green", "Blue"} {
+		if !testIdentifier(t, stmt.Members[i], name) {
+			return
+		}
+	}
+	if stmt.String() != input {
+		t.Errorf("FlagStatement.String() mismatch. expected=%q, got=%q", input, stmt.String())
+	}
+}
+
+func TestFlagStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"flag Color|Red, Red|.", "duplicate member in flag Color: Red"},
+		{"flag Color||.", "flag Color needs at least one member"},
+		{"flag Color|1|.", "Next token expected: IDENT, got INT instead"},
+	}
+	for _, tt := range tests {
+		_, p := parseProgramFromInput(tt.input)
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser error for %q", tt.input)
+			continue
+		}
+		if !strings.HasPrefix(errors[0], tt.expected) {
+			t.Errorf("wrong error for %q. expected prefix=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMatchStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match x: when 1: a. ls: b..", "match x: when 1: (a.)ls: (b.)"},
+		{"match x: when -1: a. when \"s\": b. when ay: c..",
+			"match x: when (-1): (a.)when s: (b.)when ay: (c.)"},
+		{"match x: when [a, ..rest, _]: a..", "match x: when [a, ..rest, _]: (a.)"},
+		{"match x: when {\"k\": v, (key): [w], .._}: v..", "match x: when {k: v, key: [w], .._}: (v.)"},
+		{"match x: when P|a, b: 0, c: |d||: a..", "match x: when P|a, b: 0, c: |d||: (a.)"},
+		{"match x: when [P|a|, Q|b: c|]: a..", "match x: when [P|a|, Q|b: c|]: (a.)"},
+		{"match x: when (y + 1) if y > 0: y..", "match x: when ((y + 1)) if (y > 0): (y.)"},
+		{"match p|x: when 1: a..", "match p|x: when 1: (a.)"},
+		{"match Color|Red: when (Color|Red): a..", "match Color|Red: when (Color|Red): (a.)"},
+		{"match a|b|c: when 1: a..", "match a|b|c: when 1: (a.)"},
+	}
+	for _, tt := range tests {
+		program, p := parseProgramFromInput(tt.input)
+		printErrors(t, p)
+		stmt, ok := program.Statements[0].(*ast.MatchStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.MatchStatement, got=%T", program.Statements[0])
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("MatchStatement.String() mismatch. expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestDestructuringYarStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"yar [a, ..rest] be xs.", "yar [a, ..rest] be xs."},
+		{"yar {\"k\": v, ..rest} be h.", "yar {k: v, ..rest} be h."},
+		{"yar |foo, bar: b| be c.", "yar |foo, bar: b| be c."},
+		{"yar Point|x, y: py| be p.", "yar Point|x, y: py| be p."},
+		{"yar P|a: |b|, c| be p.", "yar P|a: |b|, c| be p."},
+		{"yar P|a: q| be p.", "yar P|a: q| be p."},
+		{"yar P|a: _| be p.", "yar P|a: _| be p."},
+	}
+	for _, tt := range tests {
+		program, p := parseProgramFromInput(tt.input)
+		printErrors(t, p)
+		stmt, ok := program.Statements[0].(*ast.YarStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.YarStatement, got=%T", program.Statements[0])
+		}
+		if stmt.Pattern == nil {
+			t.Fatalf("stmt.Pattern is nil for %q", tt.input)
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("YarStatement.String() mismatch. expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestCompoundAssignmentParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x +be 1.", "FAKEYAR x +be 1."},
+		{"xs[0] *be 2.", "(xs[0] *be 2)"},
+		{"c|gold -be y + 1.", "c|gold -be (y + 1)."},
+		{"x /be 2.", "FAKEYAR x /be 2."},
+	}
+	for _, tt := range tests {
+		program, p := parseProgramFromInput(tt.input)
+		printErrors(t, p)
+		if program.Statements[0].String() != tt.expected {
+			t.Errorf("String() mismatch for %q. expected=%q, got=%q", tt.input, tt.expected, program.Statements[0].String())
+		}
+	}
+
+	_, p := parseProgramFromInput("yar x +be 1.")
+	if len(p.Errors()) == 0 || !strings.HasPrefix(p.Errors()[0], "Next token expected: BE, got +be instead") {
+		t.Errorf("expected an error for a compound form in a yar statement, got=%v", p.Errors())
+	}
+}
+
+func TestFunctionParameterOptions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"f(a, b be 1, ..rest): a..", "f(a, b be 1, ..rest) (a.)"},
+		{"f(..xs): xs..", "f(..xs) (xs.)"},
+		{"f(a, b be a): b..", "f(a, b be a) (b.)"},
+		{"g(1, b: 2, c: x + 1)", "g(1, b: 2, c: (x + 1))"},
+	}
+	for _, tt := range tests {
+		program, p := parseProgramFromInput(tt.input)
+		printErrors(t, p)
+		if program.Statements[0].String() != tt.expected {
+			t.Errorf("String() mismatch for %q. expected=%q, got=%q", tt.input, tt.expected, program.Statements[0].String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"f(a be 1, b): a..", "parameter b without a default follows one with a default"},
+		{"f(..a, b): a..", "the rest parameter must come last"},
+		{"f(a, a): a..", "duplicate parameter: a"},
+		{"f(a, ..a): a..", "duplicate parameter: a"},
+		{"f(a be 1, a): a..", "duplicate parameter: a"},
+		{"f(a be b, ..a): a..", "duplicate parameter: a"},
+		{"f(1): 1..", "Next token expected: IDENT, got INT instead"},
+		{"g(a: 1, a: 2)", "duplicate argument: a"},
+		{"g(a: 1, 2)", "positional argument after a named argument"},
+	}
+	for _, tt := range errorTests {
+		_, p := parseProgramFromInput(tt.input)
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser error for %q", tt.input)
+			continue
+		}
+		if !strings.HasPrefix(errors[0], tt.expected) {
+			t.Errorf("wrong error for %q. expected prefix=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestFunctionNameAndDoc(t *testing.T) {
+	tests := []struct {
+		input string
+		name  string
+		doc   string
+	}{
+		{`yar hail be f(x): "Says ahoy". gives x...`, "hail", "Says ahoy"},
+		{`yar hail be f(x): "only a string"...`, "hail", ""},
+		{`yar hail be f(x): gives x...`, "hail", ""},
+		{`f(x): "Says ahoy". gives x..`, "", "Says ahoy"},
+		{`chest P|n|: add be f(by): "Adds by". gives by...`, "add", "Adds by"},
+	}
+	for _, tt := range tests {
+		program, p := parseProgramFromInput(tt.input)
+		printErrors(t, p)
+		var fn *ast.FunctionLiteral
+		switch statement := program.Statements[0].(type) {
+		case *ast.YarStatement:
+			fn = statement.Value.(*ast.FunctionLiteral)
+		case *ast.ExpressionStatement:
+			fn = statement.Expression.(*ast.FunctionLiteral)
+		case *ast.ChestStatement:
+			fn = statement.Methods[0].Function
+		}
+		if fn.Name != tt.name {
+			t.Errorf("wrong name for %q. expected=%q, got=%q", tt.input, tt.name, fn.Name)
+		}
+		if fn.Doc != tt.doc {
+			t.Errorf("wrong doc for %q. expected=%q, got=%q", tt.input, tt.doc, fn.Doc)
+		}
+	}
+}
+
+func TestMatchStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match x: ls: 1..", "match needs at least one when"},
+		{"match x: when [a, a]: 1..", "a is bound twice in the same pattern"},
+		{"match x: when P|a, b: [a]|: 1..", "a is bound twice in the same pattern"},
+		{"match x: when [..a, ..b]: 1..", "a pattern can only have one rest element"},
+		{"match x: when P|a, a: 1|: 1..", "duplicate field in pattern: a"},
+		{"match x: when P|a: Q|b||: 1..", "Next token expected: :, got IDENT instead"},
+		{"match x: when a + 1: 1..", "Next token expected: :, got + instead"},
+		{"match x: when f(): 1..", "invalid pattern: f"},
+		{"match x: when 1: 1. ls: 2. ls: 3..", "expected when, ls or . in match, got LS"},
+		{"yar [a, b] 1.", "Next token expected: BE, got INT instead"},
+	}
+	for _, tt := range tests {
+		_, p := parseProgramFromInput(tt.input)
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser error for %q", tt.input)
+			continue
+		}
+		if !strings.HasPrefix(errors[0], tt.expected) {
+			t.Errorf("wrong error for %q. expected prefix=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMatchStatementErrorLeavesNoStatement(t *testing.T) {
+	program, p := parseProgramFromInput("match x: ls: 1..")
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected parser error")
+	}
+	if len(program.Statements) != 0 {
+		t.Errorf("program.Statements should be empty. got=%d", len(program.Statements))
+	}
+}
+
 func TestChestLiteralEmpty(t *testing.T) {
 	input := "||"
 	program, p := parseProgramFromInput(input)
@@ -1430,3 +1880,81 @@ func TestChestAccessFollowedByCall(t *testing.T) {
 		return
 	}
 }
+
+func TestInterpolatedStringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f"ay {name}!"`, `f"ay {name}!"`},
+		{`f"{1 + 2 * 3}"`, `f"{(1 + (2 * 3))}"`},
+		{`f"{{literal}} {x[0]}"`, `f"{{literal}} {(x[0])}"`},
+		{`f'{ {"a": 1}["a"] }'`, `f"{({a:1}[a])}"`},
+	}
+	for _, tt := range tests {
+		program, p := parseProgramFromInput(tt.input)
+		printErrors(t, p)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		is, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+		}
+		if is.String() != tt.expected {
+			t.Errorf("String() wrong. expected=%q, got=%q", tt.expected, is.String())
+		}
+	}
+}
+
+func TestInterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f"{}"`, "empty expression in interpolated string. Line: 1 Char: 5"},
+		{`f"{x"`, "unclosed '{' in interpolated string. Line: 1 Char: 4"},
+		{`f"x}"`, "single '}' is not allowed in interpolated string. Line: 1 Char: 5"},
+		{`f"{x y}"`, "unexpected IDENT in interpolated expression. Line: 1 Char: 8"},
+		{"yar s be 1.\nahoy(f\"a\n  {x y}\").", "unexpected IDENT in interpolated expression. Line: 3 Char: 8"},
+		{"yar s be 1.\nahoy(f\"a\n  {x +}\").", "no prefix parse function for EOF found. Line: 3 Char: 8"},
+	}
+	for _, tt := range tests {
+		_, p := parseProgramFromInput(tt.input)
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"s[1:2]", "(s[1:2])"},
+		{"s[:2]", "(s[:2])"},
+		{"s[1:]", "(s[1:])"},
+		{"s[:]", "(s[:])"},
+		{"s[-1 + x:len(s) - 1]", "(s[((-1) + x):(len(s) - 1)])"},
+		{"s[1:][0]", "((s[1:])[0])"},
+	}
+	for _, tt := range tests {
+		program, p := parseProgramFromInput(tt.input)
+		printErrors(t, p)
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestSliceAssignmentIsRejected(t *testing.T) {
+	_, p := parseProgramFromInput("s[1:2] be 3.")
+	errors := p.Errors()
+	if len(errors) != 1 || errors[0] != "cannot assign to a slice. Line: 1 Char: 11" {
+		t.Fatalf("expected slice assignment error, got=%v", errors)
+	}
+}
@@ -41,7 +41,7 @@ func Start(in io.Reader, out io.Writer) {
 
 		evaluated := evaluator.Eval(program, ns)
 		if evaluated != nil && evaluated != evaluator.MT {
-			io.WriteString(out, evaluated.AsString())
+			io.WriteString(out, evaluator.Display(evaluated))
 			io.WriteString(out, "\n")
 		}
 		fmt.Print(writer.GetOutput())
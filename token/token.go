@@ -20,6 +20,10 @@ const (
 	GREATEREQ = ">="
 	EQUAL     = "="
 	NOTEQUAL  = "<>"
+	PLUSBE    = "+be"
+	MINUSBE   = "-be"
+	STARBE    = "*be"
+	FSLASHBE  = "/be"
 	// Delimiters
 	SQUOTE    = "'"
 	COMMA     = ","
@@ -33,22 +37,27 @@ const (
 	LBRACKET  = "["
 	RBRACKET  = "]"
 	PIPE      = "|"
+	QUESTION  = "?"
 	// Keywords
-	F      = "F"
-	YAR    = "YAR"
-	GIVES  = "GIVES"
-	IF     = "IF"
-	LSIF   = "LSIF"
-	LS     = "LS"
-	OR     = "OR"
-	AND    = "AND"
-	TRUE   = "TRUE"
-	FALSE  = "FALSE"
-	STRING = "STRING"
-	FOR    = "4"
-	BREAK  = "BREAK"
-	PORT   = "PORT"
-	CHEST  = "CHEST"
+	F       = "F"
+	YAR     = "YAR"
+	GIVES   = "GIVES"
+	IF      = "IF"
+	LSIF    = "LSIF"
+	LS      = "LS"
+	OR      = "OR"
+	AND     = "AND"
+	TRUE    = "TRUE"
+	FALSE   = "FALSE"
+	STRING  = "STRING"
+	FSTRING = "FSTRING"
+	FOR     = "4"
+	BREAK   = "BREAK"
+	PORT    = "PORT"
+	CHEST   = "CHEST"
+	FLAG    = "FLAG"
+	MATCH   = "MATCH"
+	WHEN    = "WHEN"
 )
 
 type TokenType string
@@ -70,7 +79,7 @@ func (tok *Token) IsNot(t TokenType) bool {
 
 func (tok *Token) IsBlockTerminator() bool {
 	switch tok.Type {
-	case LS, LSIF, PERIOD, EOF:
+	case LS, LSIF, WHEN, PERIOD, EOF:
 		return true
 	default:
 		return false
@@ -79,13 +88,29 @@ func (tok *Token) IsBlockTerminator() bool {
 
 func (tok *Token) IsExpressionTerminator() bool {
 	switch tok.Type {
-	case PERIOD, COLOGNE, BE:
+	case PERIOD, COLOGNE, BE, PLUSBE, MINUSBE, STARBE, FSLASHBE:
 		return true
 	default:
 		return false
 	}
 }
 
+// IsAssignment reports whether the token is `be` or a compound form like `+be`.
+func (tok *Token) IsAssignment() bool {
+	return tok.Type == BE || tok.CompoundOperator() != ""
+}
+
+// CompoundOperator gives the operator a compound assignment such as `+be`
+// applies, or "" for any other token.
+func (tok *Token) CompoundOperator() string {
+	switch tok.Type {
+	case PLUSBE, MINUSBE, STARBE, FSLASHBE:
+		return string(tok.Type[:1])
+	default:
+		return ""
+	}
+}
+
 const (
 	_ int = iota
 	PREC_LOWEST
@@ -155,6 +180,12 @@ func LookupIdent(ident string) TokenType {
 		return PORT
 	case "chest":
 		return CHEST
+	case "flag":
+		return FLAG
+	case "match":
+		return MATCH
+	case "when":
+		return WHEN
 	default:
 		return IDENT
 	}